@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/config"
+	"github.com/chainguard-dev/yacls/v2/pkg/controls"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
+	"github.com/chainguard-dev/yacls/v2/pkg/evidence"
+	"github.com/chainguard-dev/yacls/v2/pkg/justify"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"github.com/chainguard-dev/yacls/v2/pkg/review"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+	"k8s.io/klog/v2"
+)
+
+func bundleCommand() *command {
+	c := newCommand("bundle", "["+config.DefaultFile+"]", "Package the evidence of an audit period into a zip: raw exports, artifacts, collection steps, changes and review sign-offs.")
+	period := c.flags.String("period", "", "name of the audit period, such as 2024-q3")
+	out := c.flags.String("out", "", "path to write the bundle to (default: evidence-<period>.zip)")
+	artifactsDir := c.flags.String("artifacts-dir", "", "directory of stored artifacts holding prior revisions and review campaigns (default: the configured output directory)")
+	prior := c.flags.String("prior", "", "directory of artifacts from the prior period, to report the changes since")
+	priorRev := c.flags.String("prior-rev", "", "git revision of --artifacts-dir from the prior period, such as q2 or before:90d, to report the changes since")
+	campaign := c.flags.String("campaign", "", "access review campaign within --artifacts-dir whose decisions and sign-offs to include")
+	justifications := c.flags.String("justifications", "", "file mapping changes to the tickets which justify them")
+	mapping := c.flags.String("controls", "", "control mapping file: also group the evidence by compliance control")
+	recipients := c.flags.String("age-recipients", "", "comma-separated age public keys (or files containing them) to encrypt the bundle to")
+	proc := processingFlags(c.flags)
+	common := commonFlags(c.flags)
+
+	c.run = func(ctx context.Context, args []string) error {
+		if *period == "" || strings.ContainsAny(*period, `/\`) || strings.HasPrefix(*period, ".") {
+			return fmt.Errorf("a valid --period is required, such as 2024-q3")
+		}
+		if *prior != "" && *priorRev != "" {
+			return fmt.Errorf("--prior and --prior-rev are mutually exclusive")
+		}
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		path := config.DefaultFile
+		if len(args) > 0 {
+			path = args[0]
+		}
+		conf, err := config.Load(path)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+
+		dir := conf.Output.Dir
+		if *artifactsDir != "" {
+			dir = *artifactsDir
+		}
+		if dir == "" && (*priorRev != "" || *campaign != "") {
+			return fmt.Errorf("--prior-rev and --campaign require --artifacts-dir or a configured output directory")
+		}
+		rs := conf.Output.AgeRecipients
+		if *recipients != "" {
+			rs = strings.Split(*recipients, ",")
+		}
+		workers := proc.workers
+		if conf.Workers > 0 && !flagSet(c.flags, "workers") {
+			workers = conf.Workers
+		}
+		if flagSet(c.flags, "timeout") {
+			overrideTimeouts(conf, proc.timeout)
+		}
+
+		b, err := buildBundle(ctx, conf, *period, workers, proc.timeout)
+		if err != nil {
+			return err
+		}
+
+		var priorArtifacts map[string]*platform.Artifact
+		switch {
+		case *prior != "":
+			priorArtifacts, err = yacls.LoadDir(*prior, identities)
+		case *priorRev != "":
+			priorArtifacts, err = yacls.LoadRevision(ctx, dir, *priorRev, identities)
+		}
+		// evidence must be complete, so refuse to compare against a partially readable period
+		if err != nil {
+			return fmt.Errorf("prior period: %w", err)
+		}
+		if priorArtifacts != nil {
+			if err := b.SetPrior(priorArtifacts); err != nil {
+				return err
+			}
+		}
+		if *justifications != "" {
+			if !b.HasPrior {
+				return fmt.Errorf("--justifications requires --prior or --prior-rev")
+			}
+			rules, err := justify.Load(*justifications)
+			if err != nil {
+				return err
+			}
+			justify.Annotate(b.Changes, rules)
+			b.Justified = true
+			for _, s := range b.Systems {
+				justify.Annotate(s.Changes, rules)
+			}
+		}
+
+		if *mapping != "" {
+			m, err := controls.Load(*mapping)
+			if err != nil {
+				return err
+			}
+			b.SetControls(m)
+		}
+
+		if *campaign != "" {
+			cpath, err := review.Find(dir, *campaign)
+			if err != nil {
+				return err
+			}
+			rc, err := review.Load(cpath, identities)
+			if err != nil {
+				return err
+			}
+			b.SetCampaign(rc)
+		}
+
+		dest := *out
+		if dest == "" {
+			dest = "evidence-" + *period + ".zip"
+		}
+		return writeBundle(b, dest, rs)
+	}
+	return c
+}
+
+// buildBundle processes every source described within a configuration file into a bundle, noting any missing inputs.
+func buildBundle(ctx context.Context, c *config.Config, period string, workers int, timeout time.Duration) (*evidence.Bundle, error) {
+	jobs, owners, err := sourceJobs(c, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := yacls.Process(ctx, jobs, yacls.Options{Workers: workers, GCPMemberCache: platform.NewGCPMemberCache()})
+	if err != nil {
+		return nil, err
+	}
+
+	// a source is only present if one of its inputs produced an artifact
+	found := make([]bool, len(c.Sources))
+	for x, a := range results {
+		if a != nil {
+			found[owners[x]] = true
+		}
+	}
+
+	b := evidence.New(period, jobs, results)
+	for x, s := range c.Sources {
+		if !found[x] {
+			klog.Errorf("expected source is missing inputs: %s", s)
+			b.Missing = append(b.Missing, s.String())
+		}
+	}
+	for _, s := range b.Systems {
+		reportDiagnostics(s.Artifact)
+	}
+	return b, nil
+}
+
+// writeBundle stores a bundle as a zip, encrypting it if any recipients are given.
+func writeBundle(b *evidence.Bundle, path string, recipientSpecs []string) error {
+	recipients, err := encrypt.ParseRecipients(recipientSpecs)
+	if err != nil {
+		return fmt.Errorf("age recipients: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := b.Write(buf); err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+
+	bs := buf.Bytes()
+	if len(recipients) > 0 {
+		if bs, err = encrypt.Encrypt(bs, recipients); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(path, encrypt.Extension) {
+			path += encrypt.Extension
+		}
+	}
+
+	if err := os.WriteFile(path, bs, 0o600); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	klog.Infof("wrote evidence for %d systems to %s", len(b.Systems), path)
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"github.com/chainguard-dev/yacls/v2/pkg/justify"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+)
+
+func compareCommand() *command {
+	c := newCommand("compare", "<from> <to>", "Summarize the changes between two artifacts, two directories of artifacts, or two git revisions of a directory, as CSV.")
+	common := commonFlags(c.flags)
+	gitDir := c.flags.String("git", "", "directory of artifacts within a local git repository: compare the revisions <from> and <to> (default: the working tree) instead of paths")
+	justifications := c.flags.String("justifications", "", "file mapping changes to the tickets which justify them: annotates each change with its ticket, or as unjustified")
+	justifyCommits := c.flags.Bool("justify-commits", false, "with --git, justify changes using the tickets named within commit messages, such as 'Ticket: SEC-123'")
+	unjustifiedOnly := c.flags.Bool("unjustified", false, "only output changes without a justification")
+	privilegedOnly := c.flags.Bool("privileged", false, "only output changes to roles, permissions or groups matching --privileged-roles")
+	privilegedRoles := c.flags.String("privileged-roles", yacls.DefaultPrivilegedRoles.String(), "regular expression matching privileged roles, permissions and groups")
+
+	c.run = func(ctx context.Context, args []string) error {
+		if *gitDir != "" && len(args) == 1 {
+			args = append(args, "")
+		}
+		if len(args) != 2 {
+			c.flags.Usage()
+			return fmt.Errorf("expected 2 arguments, got %d", len(args))
+		}
+		if *justifyCommits && *gitDir == "" {
+			return fmt.Errorf("--justify-commits requires --git")
+		}
+		if *unjustifiedOnly && *justifications == "" && !*justifyCommits {
+			return fmt.Errorf("--unjustified requires --justifications or --justify-commits")
+		}
+		privileged, err := regexp.Compile(*privilegedRoles)
+		if err != nil {
+			return fmt.Errorf("privileged roles: %w", err)
+		}
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		from, to := args[0], args[1]
+		var changes []compare.Change
+		switch {
+		case *gitDir != "":
+			changes, err = yacls.CompareRevisions(ctx, *gitDir, from, to, identities)
+		case isDir(from):
+			changes, err = yacls.CompareDirs(from, to, identities)
+		default:
+			changes, err = yacls.CompareFiles(from, to, identities)
+		}
+		// directory and revision comparisons continue past unreadable files, so output whatever could be compared
+		if changes == nil {
+			return err
+		}
+
+		if *privilegedOnly {
+			changes = compare.Privileged(changes, privileged)
+		}
+		justified := *justifications != "" || *justifyCommits
+		if justified {
+			rules, jerr := justificationRules(ctx, *justifications, *justifyCommits, *gitDir, from, to)
+			if jerr != nil {
+				return jerr
+			}
+			justify.Annotate(changes, rules)
+			if *unjustifiedOnly {
+				changes = justify.Unjustified(changes)
+			}
+		}
+
+		s, merr := compare.MarshalCSV(changes, justified)
+		if merr != nil {
+			return fmt.Errorf("marshal: %w", merr)
+		}
+		fmt.Println(s)
+
+		if err != nil {
+			return fmt.Errorf("some artifacts could not be compared:\n%w", err)
+		}
+		return nil
+	}
+	return c
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// justificationRules reads rules from a justifications file, followed by those from commit messages if requested.
+func justificationRules(ctx context.Context, path string, commits bool, gitDir string, from string, to string) ([]justify.Rule, error) {
+	rules := []justify.Rule{}
+	if path != "" {
+		rs, err := justify.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rs...)
+	}
+	if commits {
+		rs, err := yacls.CommitJustifications(ctx, gitDir, from, to, identities)
+		if err != nil {
+			return nil, fmt.Errorf("commit justifications: %w", err)
+		}
+		rules = append(rules, rs...)
+	}
+	return rules, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"github.com/chainguard-dev/yacls/v2/pkg/controls"
+	"github.com/chainguard-dev/yacls/v2/pkg/justify"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+	"github.com/gocarina/gocsv"
+	"gopkg.in/yaml.v3"
+)
+
+func controlsCommand() *command {
+	c := newCommand("controls", "<artifact dir>", "Report the artifacts and changes which serve as evidence for each compliance control, such as SOC 2 or ISO 27001.")
+	mapping := c.flags.String("mapping", "", "file tagging kinds and finding types with the control IDs they serve as evidence for")
+	prior := c.flags.String("prior", "", "directory of artifacts from the prior period, to cite the changes since as findings")
+	priorRev := c.flags.String("prior-rev", "", "git revision of <artifact dir> from the prior period, such as q2 or before:90d, to cite the changes since as findings")
+	justifications := c.flags.String("justifications", "", "file mapping changes to the tickets which justify them, so that unjustified changes can be cited")
+	format := c.flags.String("format", "yaml", "output format: yaml or csv")
+	common := commonFlags(c.flags)
+
+	c.run = func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			c.flags.Usage()
+			return fmt.Errorf("expected an artifact directory")
+		}
+		if *mapping == "" {
+			return fmt.Errorf("--mapping is required")
+		}
+		if *prior != "" && *priorRev != "" {
+			return fmt.Errorf("--prior and --prior-rev are mutually exclusive")
+		}
+		if *justifications != "" && *prior == "" && *priorRev == "" {
+			return fmt.Errorf("--justifications requires --prior or --prior-rev")
+		}
+		if *format != "yaml" && *format != "csv" {
+			return fmt.Errorf("unknown format %q: expected yaml or csv", *format)
+		}
+		// definitions and plugins must be registered before the mapping's kinds are checked
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		m, err := controls.Load(*mapping)
+		if err != nil {
+			return err
+		}
+
+		dir := args[0]
+		as, err := citedArtifacts(dir)
+		if err != nil {
+			return err
+		}
+
+		var cs []compare.Change
+		switch {
+		case *prior != "":
+			cs, err = yacls.CompareDirs(*prior, dir, identities)
+		case *priorRev != "":
+			cs, err = yacls.CompareRevisions(ctx, dir, *priorRev, "", identities)
+		}
+		// evidence must be complete, so refuse to cite changes from a partial comparison
+		if err != nil {
+			return fmt.Errorf("prior period: %w", err)
+		}
+		if *justifications != "" {
+			rules, err := justify.Load(*justifications)
+			if err != nil {
+				return err
+			}
+			justify.Annotate(cs, rules)
+		}
+
+		entries := controls.Report(m, as, cs)
+		var out []byte
+		if *format == "csv" {
+			rows := controls.Rows(entries)
+			var s string
+			s, err = gocsv.MarshalString(&rows)
+			out = []byte(s)
+		} else {
+			out, err = yaml.Marshal(entries)
+		}
+		if err != nil {
+			return fmt.Errorf("marshal: %w", err)
+		}
+		fmt.Print(string(out))
+		return nil
+	}
+	return c
+}
+
+// citedArtifacts reads every artifact within dir, ordered by source, refusing to continue if any are unreadable.
+func citedArtifacts(dir string) ([]controls.Artifact, error) {
+	byKey, paths, err := yacls.LoadDirPaths(dir, identities)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	as := []controls.Artifact{}
+	for _, k := range keys {
+		as = append(as, controls.NewArtifact(paths[k], byKey[k]))
+	}
+	return as, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/config"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+)
+
+func generateCommand() *command {
+	c := newCommand("generate", "[input ...]", "Generate artifacts from exported inputs (files, directories or zip archives), or from gcloud for GCP kinds.")
+	input := c.flags.String("input", "", "path to input file, directory or zip archive")
+	inDir := c.flags.String("in-dir", "", "process all inputs found directly within this directory, guessing kinds")
+	kind := c.flags.String("kind", "", "kind of input to process, see 'yacls kinds' (default: detected from each input)")
+	project := c.flags.String("project", "", "specific project to process within the kind")
+	gcpIdentityProject := c.flags.String("gcp-identity-project", "", "project to use for GCP Cloud Identity lookups")
+	out := outputFlags(c.flags)
+	proc := processingFlags(c.flags)
+	common := commonFlags(c.flags)
+
+	c.run = func(ctx context.Context, args []string) error {
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		paths := args
+		if *input != "" {
+			paths = append([]string{*input}, paths...)
+		}
+
+		inputs, err := collectInputs(paths, *inDir)
+		if err != nil {
+			return fmt.Errorf("inputs: %w", err)
+		}
+
+		// some workflows don't require an input
+		if *kind != "" {
+			p, err := platform.New(*kind)
+			if err != nil {
+				return fmt.Errorf("unable to create %q platform: %w", *kind, err)
+			}
+			if p.Description().NoInputRequired {
+				inputs = append(inputs, yacls.Input{})
+			}
+		}
+
+		if len(inputs) == 0 {
+			c.flags.Usage()
+			return fmt.Errorf("found no inputs or kind flag to work with")
+		}
+
+		src := config.Source{Kind: *kind, Project: *project, GCPIdentityProject: *gcpIdentityProject, Timeout: proc.timeout}
+		jobs := []yacls.Job{}
+		for _, i := range inputs {
+			jobs = append(jobs, yacls.Job{Input: i, Source: src})
+		}
+
+		artifacts, err := yacls.Generate(ctx, jobs, yacls.Options{Workers: proc.workers, GCPMemberCache: platform.NewGCPMemberCache()})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range artifacts {
+			reportDiagnostics(a)
+		}
+
+		return writeArtifacts(artifacts, out.dir, strings.Split(out.ageRecipients, ","))
+	}
+	return c
+}
+
+// collectInputs returns the inputs found at paths, and directly within inDir.
+func collectInputs(paths []string, inDir string) ([]yacls.Input, error) {
+	inputs, err := yacls.ReadInputs(paths, identities)
+	if err != nil {
+		return nil, err
+	}
+	if inDir != "" {
+		is, err := yacls.DirInputs(inDir, identities)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, is...)
+	}
+	return inputs, nil
+}
@@ -5,6 +5,7 @@ go 1.23.0
 toolchain go1.24.2
 
 require (
+	filippo.io/age v1.2.1
 	github.com/PuerkitoBio/goquery v1.10.3
 	github.com/gocarina/gocsv v0.0.0-20240520201108-78e41c74b4b1
 	gopkg.in/yaml.v3 v3.0.1
@@ -14,5 +15,7 @@ require (
 require (
 	github.com/andybalholm/cascadia v1.3.3 // indirect
 	github.com/go-logr/logr v1.4.3 // indirect
+	golang.org/x/crypto v0.39.0 // indirect
 	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
 )
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"github.com/chainguard-dev/yacls/v2/pkg/server"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+)
+
+func serveCommand() *command {
+	c := newCommand("serve", "", "Serve the web UI for processing uploaded inputs, listening on $PORT (default: 8080).")
+	timeout := c.flags.Duration("timeout", 0, "time limit for processing each upload, such as 1m (default: no limit)")
+	timelineDirs := c.flags.String("timeline-dirs", "", "comma-separated list of snapshot directories to serve account timelines from, oldest first")
+	timelineGit := c.flags.String("timeline-git", "", "artifact or directory of artifacts within a local git repository to serve account timelines from")
+	common := commonFlags(c.flags)
+
+	c.run = func(_ context.Context, _ []string) error {
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		s := server.New()
+		s.Identities = identities
+		s.Timeout = *timeout
+		if *timelineDirs != "" || *timelineGit != "" {
+			dirs := []string{}
+			if *timelineDirs != "" {
+				dirs = strings.Split(*timelineDirs, ",")
+			}
+			s.Snapshots = func(ctx context.Context) ([]yacls.Snapshot, error) {
+				return snapshots(ctx, dirs, *timelineGit)
+			}
+		}
+		return s.Serve()
+	}
+	return c
+}
+
+func kindsCommand() *command {
+	c := newCommand("kinds", "", "List the kinds of input yacls can process.")
+	common := commonFlags(c.flags)
+
+	c.run = func(_ context.Context, _ []string) error {
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tNAME\tINPUT\tFILENAME")
+		for _, p := range platform.Available() {
+			d := p.Description()
+			input := "required"
+			if d.NoInputRequired {
+				input = "none"
+			}
+			filename := "-"
+			if d.MatchingFilename != nil {
+				filename = d.MatchingFilename.String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Kind, d.Name, input, filename)
+		}
+		return w.Flush()
+	}
+	return c
+}
+
+func explainCommand() *command {
+	c := newCommand("explain", "<kind>", "Explain how to collect the input for a kind, and what it is expected to contain.")
+	input := c.flags.String("input", "", "path the input will be saved to, for the collection steps")
+	project := c.flags.String("project", "", "specific project to process within the kind, for the collection steps")
+	common := commonFlags(c.flags)
+
+	c.run = func(_ context.Context, args []string) error {
+		if len(args) != 1 {
+			c.flags.Usage()
+			return fmt.Errorf("expected a kind, see 'yacls kinds'")
+		}
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		p, err := platform.New(args[0])
+		if err != nil {
+			return err
+		}
+		d := p.Description()
+
+		steps, err := platform.RenderSteps(d.Steps, platform.Config{Kind: d.Kind, Path: *input, Project: *project})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("# %s (%s)\n\n", d.Name, d.Kind)
+		for x, s := range steps {
+			fmt.Printf("%d. %s\n", x+1, s)
+		}
+
+		if d.MatchingFilename != nil {
+			fmt.Printf("\nInputs named like %s are recognized without --kind.\n", d.MatchingFilename)
+		}
+		if len(d.Schema.Headers) > 0 {
+			fmt.Printf("\nExpected CSV columns: %s\n", strings.Join(d.Schema.Headers, ", "))
+		}
+		if len(d.Schema.Selectors) > 0 {
+			sels := []string{}
+			for _, s := range d.Schema.Selectors {
+				sels = append(sels, s.Selector)
+			}
+			fmt.Printf("\nExpected HTML elements: %s\n", strings.Join(sels, ", "))
+		}
+		return nil
+	}
+	return c
+}
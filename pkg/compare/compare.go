@@ -2,11 +2,43 @@ package compare
 
 import (
 	"fmt"
+	"regexp"
 	"slices"
+	"sort"
+	"strings"
 
 	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"github.com/gocarina/gocsv"
 )
 
+// Unjustified is the Ticket of a change which no justification covers.
+const Unjustified = "unjustified"
+
+// Types of change, which prefix each Change.Mod.
+const (
+	AddSource        = "add source"
+	RemoveSource     = "remove source"
+	AddUser          = "add user"
+	RemoveUser       = "remove user"
+	Renamed          = "renamed"
+	NewStatus        = "new status"
+	StatusChange     = "status change"
+	RoleChange       = "role change"
+	AddPermission    = "add permission"
+	RemovePermission = "remove permission"
+	JoinedGroup      = "joined group"
+	LeftGroup        = "left group"
+	GainedPermission = "gained permission"
+	LostPermission   = "lost permission"
+)
+
+// FindingTypes are the types of change Summary reports, as returned by Change.FindingType.
+var FindingTypes = []string{
+	AddSource, RemoveSource,
+	AddUser, RemoveUser, Renamed, NewStatus, StatusChange, RoleChange,
+	AddPermission, RemovePermission, JoinedGroup, LeftGroup, GainedPermission, LostPermission,
+}
+
 type Change struct {
 	Kind     string
 	ID       string
@@ -14,6 +46,112 @@ type Change struct {
 	Mod      string
 	FromDate string
 	ToDate   string
+	// Ticket justifies the change, if justifications were checked: a ticket ID, or Unjustified
+	Ticket string
+	// Grants are the roles and permissions of an added or removed user, to judge whether the change is privileged
+	Grants []string `csv:"-"`
+}
+
+// FindingType returns the type of change, without the details: "role change" rather than `role change: "a" to "b"`.
+func (c Change) FindingType() string {
+	t, _, _ := strings.Cut(c.Mod, ":")
+	return t
+}
+
+// privilegeTypes are the finding types whose details name a role, permission or group.
+var privilegeTypes = []string{
+	RoleChange, AddPermission, RemovePermission, JoinedGroup, LeftGroup, GainedPermission, LostPermission,
+}
+
+// Privileged returns the changes to roles, permissions or groups matching privileged, and the users added or removed
+// while holding a matching role or permission.
+func Privileged(cs []Change, privileged *regexp.Regexp) []Change {
+	out := []Change{}
+	for _, c := range cs {
+		t, details, _ := strings.Cut(c.Mod, ":")
+		switch {
+		case slices.Contains(privilegeTypes, t) && privileged.MatchString(details):
+			out = append(out, c)
+		case t == AddUser || t == RemoveUser:
+			if slices.ContainsFunc(c.Grants, privileged.MatchString) {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+// grants returns the roles and permissions held by a user.
+func grants(u platform.User) []string {
+	gs := []string{}
+	for _, g := range append(append([]string{u.Role}, u.Roles...), u.Permissions...) {
+		if g != "" {
+			gs = append(gs, g)
+		}
+	}
+	return gs
+}
+
+// MarshalCSV renders changes as CSV, with a Ticket column only if justifications were checked.
+func MarshalCSV(cs []Change, tickets bool) (string, error) {
+	if tickets {
+		return gocsv.MarshalString(&cs)
+	}
+
+	type row struct {
+		Kind     string
+		ID       string
+		Entity   string
+		Mod      string
+		FromDate string
+		ToDate   string
+	}
+	rows := []row{}
+	for _, c := range cs {
+		rows = append(rows, row{Kind: c.Kind, ID: c.ID, Entity: c.Entity, Mod: c.Mod, FromDate: c.FromDate, ToDate: c.ToDate})
+	}
+	return gocsv.MarshalString(&rows)
+}
+
+// SourceAdded describes a source which only appears within the newer set of artifacts.
+func SourceAdded(to platform.Artifact) Change {
+	return Change{Kind: to.Metadata.Kind, ID: sourceID(to), Entity: to.Metadata.Name, Mod: AddSource, ToDate: to.Metadata.SourceDate}
+}
+
+// SourceRemoved describes a source which only appears within the older set of artifacts.
+func SourceRemoved(from platform.Artifact) Change {
+	return Change{Kind: from.Metadata.Kind, ID: sourceID(from), Entity: from.Metadata.Name, Mod: RemoveSource, FromDate: from.Metadata.SourceDate}
+}
+
+func sourceID(a platform.Artifact) string {
+	if a.Metadata.ID == "" {
+		return a.Metadata.Kind
+	}
+	return a.Metadata.ID
+}
+
+// accounts returns the users of an artifact followed by those only listed within its permissions, such as the
+// members of GCP IAM bindings, ordered by account.
+func accounts(a platform.Artifact) []platform.User {
+	us := append([]platform.User{}, a.Users...)
+	seen := map[string]bool{}
+	for _, u := range us {
+		seen[u.Account] = true
+	}
+
+	accts := []string{}
+	for acct := range a.Permissions.Users {
+		if !seen[acct] {
+			accts = append(accts, acct)
+		}
+	}
+	sort.Strings(accts)
+	for _, acct := range accts {
+		u := a.Permissions.Users[acct]
+		u.Account = acct
+		us = append(us, u)
+	}
+	return us
 }
 
 func Summary(from platform.Artifact, to platform.Artifact) ([]Change, error) {
@@ -34,7 +172,10 @@ func Summary(from platform.Artifact, to platform.Artifact) ([]Change, error) {
 		id = kind
 	}
 
-	for _, u := range from.Users {
+	fromUsers := accounts(from)
+	toUsers := accounts(to)
+
+	for _, u := range fromUsers {
 		fromU[u.Account] = u
 	}
 
@@ -43,22 +184,37 @@ func Summary(from platform.Artifact, to platform.Artifact) ([]Change, error) {
 		fromGroupPerms[g.Name] = g.Permissions
 	}
 
-	for _, u := range to.Users {
+	for _, u := range toUsers {
 		toU[u.Account] = u
+	}
+
+	// old account -> new account, and the reverse
+	renamedTo := renames(fromU, toU)
+	renamedFrom := map[string]string{}
+	for old, acct := range renamedTo {
+		renamedFrom[acct] = old
+	}
+
+	for _, u := range toUsers {
 		fu, exists := fromU[u.Account]
 		if !exists {
-			cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: "add user", FromDate: fromDate, ToDate: toDate})
-			continue
+			old, renamed := renamedFrom[u.Account]
+			if !renamed {
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: AddUser, FromDate: fromDate, ToDate: toDate, Grants: grants(u)})
+				continue
+			}
+			cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: fmt.Sprintf("%s: %q to %q", Renamed, old, u.Account), FromDate: fromDate, ToDate: toDate})
+			fu = fromU[old]
 		}
 		if u.Status != fu.Status {
 			if fu.Status == "" {
-				cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: fmt.Sprintf("new status: %s", u.Status), FromDate: fromDate, ToDate: toDate})
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: fmt.Sprintf("%s: %s", NewStatus, u.Status), FromDate: fromDate, ToDate: toDate})
 			} else {
-				cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: fmt.Sprintf("status change: %q to %q", fu.Status, u.Status), FromDate: fromDate, ToDate: toDate})
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: fmt.Sprintf("%s: %q to %q", StatusChange, fu.Status, u.Status), FromDate: fromDate, ToDate: toDate})
 			}
 		}
 		if u.Role != fu.Role {
-			cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: fmt.Sprintf("role change: %q to %q", fu.Role, u.Role), FromDate: fromDate, ToDate: toDate})
+			cs = append(cs, Change{Kind: kind, ID: id, Entity: u.Account, Mod: fmt.Sprintf("%s: %q to %q", RoleChange, fu.Role, u.Role), FromDate: fromDate, ToDate: toDate})
 		}
 
 	}
@@ -71,35 +227,38 @@ func Summary(from platform.Artifact, to platform.Artifact) ([]Change, error) {
 	for acct, fu := range fromU {
 		tu, exists := toU[acct]
 		if !exists {
-			cs = append(cs, Change{Kind: kind, ID: id, Entity: fu.Account, Mod: "remove user", FromDate: fromDate, ToDate: toDate})
-			continue
+			if renamedTo[acct] == "" {
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: fu.Account, Mod: RemoveUser, FromDate: fromDate, ToDate: toDate, Grants: grants(fu)})
+				continue
+			}
+			tu = toU[renamedTo[acct]]
 		}
 
 		for _, p := range fu.Permissions {
 			if !slices.Contains(tu.Permissions, p) {
-				cs = append(cs, Change{Kind: kind, ID: id, Entity: fu.Account, Mod: fmt.Sprintf("remove permission: %s", p), FromDate: fromDate, ToDate: toDate})
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: fu.Account, Mod: fmt.Sprintf("%s: %s", RemovePermission, p), FromDate: fromDate, ToDate: toDate})
 			}
 		}
 
 		for _, p := range tu.Permissions {
 			if !slices.Contains(fu.Permissions, p) {
-				cs = append(cs, Change{Kind: kind, ID: id, Entity: fu.Account, Mod: fmt.Sprintf("add permission: %s", p), FromDate: fromDate, ToDate: toDate})
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: fu.Account, Mod: fmt.Sprintf("%s: %s", AddPermission, p), FromDate: fromDate, ToDate: toDate})
 			}
 		}
 	}
 
 	for name, members := range fromGroups {
 		for _, m := range members {
-			if !slices.Contains(toGroups[name], m) {
-				cs = append(cs, Change{Kind: kind, ID: id, Entity: m, Mod: fmt.Sprintf("left group: %s", name), FromDate: fromDate, ToDate: toDate})
+			if !slices.Contains(toGroups[name], m) && !slices.Contains(toGroups[name], renamedTo[m]) {
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: m, Mod: fmt.Sprintf("%s: %s", LeftGroup, name), FromDate: fromDate, ToDate: toDate})
 			}
 		}
 	}
 
 	for name, members := range toGroups {
 		for _, m := range members {
-			if !slices.Contains(fromGroups[name], m) {
-				cs = append(cs, Change{Kind: kind, ID: id, Entity: m, Mod: fmt.Sprintf("joined group: %s", name), FromDate: fromDate, ToDate: toDate})
+			if !slices.Contains(fromGroups[name], m) && !slices.Contains(fromGroups[name], renamedFrom[m]) {
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: m, Mod: fmt.Sprintf("%s: %s", JoinedGroup, name), FromDate: fromDate, ToDate: toDate})
 			}
 		}
 	}
@@ -107,7 +266,7 @@ func Summary(from platform.Artifact, to platform.Artifact) ([]Change, error) {
 	for g, ps := range fromGroupPerms {
 		for _, p := range ps {
 			if !slices.Contains(toGroupPerms[g], p) {
-				cs = append(cs, Change{Kind: kind, ID: id, Entity: g, Mod: fmt.Sprintf("lost permission: %s", p), FromDate: fromDate, ToDate: toDate})
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: g, Mod: fmt.Sprintf("%s: %s", LostPermission, p), FromDate: fromDate, ToDate: toDate})
 			}
 		}
 	}
@@ -115,10 +274,66 @@ func Summary(from platform.Artifact, to platform.Artifact) ([]Change, error) {
 	for g, ps := range toGroupPerms {
 		for _, p := range ps {
 			if !slices.Contains(fromGroupPerms[g], p) {
-				cs = append(cs, Change{Kind: kind, ID: id, Entity: g, Mod: fmt.Sprintf("gained permission: %s", p), FromDate: fromDate, ToDate: toDate})
+				cs = append(cs, Change{Kind: kind, ID: id, Entity: g, Mod: fmt.Sprintf("%s: %s", GainedPermission, p), FromDate: fromDate, ToDate: toDate})
 			}
 		}
 	}
 
 	return cs, nil
 }
+
+// renames pairs accounts which disappeared with accounts which appeared, where a stable attribute shows that they
+// belong to the same person: a UID, an SSO identity, or failing those, a name. Values shared by several candidates
+// on either side are ambiguous, and never paired. Returns a map of old accounts to new accounts.
+func renames(from map[string]platform.User, to map[string]platform.User) map[string]string {
+	removed := map[string]platform.User{}
+	for acct, u := range from {
+		if _, ok := to[acct]; !ok {
+			removed[acct] = u
+		}
+	}
+	added := map[string]platform.User{}
+	for acct, u := range to {
+		if _, ok := from[acct]; !ok {
+			added[acct] = u
+		}
+	}
+
+	attrs := []func(platform.User) string{
+		func(u platform.User) string { return u.UID },
+		func(u platform.User) string {
+			if u.SSO == "NOT_CONFIGURED" {
+				return ""
+			}
+			return u.SSO
+		},
+		func(u platform.User) string { return u.Name },
+	}
+
+	pairs := map[string]string{}
+	for _, attr := range attrs {
+		olds := index(removed, attr)
+		news := index(added, attr)
+		for v, oldAccts := range olds {
+			newAccts := news[v]
+			if len(oldAccts) != 1 || len(newAccts) != 1 {
+				continue
+			}
+			pairs[oldAccts[0]] = newAccts[0]
+			delete(removed, oldAccts[0])
+			delete(added, newAccts[0])
+		}
+	}
+	return pairs
+}
+
+// index groups accounts by the value of an attribute, ignoring accounts where it is empty.
+func index(us map[string]platform.User, attr func(platform.User) string) map[string][]string {
+	idx := map[string][]string{}
+	for acct, u := range us {
+		if v := attr(u); v != "" {
+			idx[v] = append(idx[v], acct)
+		}
+	}
+	return idx
+}
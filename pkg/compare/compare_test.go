@@ -0,0 +1,211 @@
+package compare
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+func artifact(date string, users ...platform.User) platform.Artifact {
+	return platform.Artifact{Metadata: &platform.Source{Kind: "vercel", SourceDate: date}, Users: users}
+}
+
+func TestPrivileged(t *testing.T) {
+	privileged := regexp.MustCompile(`(?i)owner|admin`)
+
+	tests := []struct {
+		name string
+		from []platform.User
+		to   []platform.User
+		want []string
+	}{
+		{
+			name: "added admin",
+			to:   []platform.User{{Account: "a@example.com", Role: "admin"}, {Account: "b@example.com", Role: "member"}},
+			want: []string{"a@example.com: add user"},
+		},
+		{
+			name: "removed owner",
+			from: []platform.User{{Account: "a@example.com", Role: "owner"}, {Account: "b@example.com", Role: "member"}},
+			want: []string{"a@example.com: remove user"},
+		},
+		{
+			name: "added user with privileged permission",
+			to:   []platform.User{{Account: "a@example.com", Permissions: []string{"roles/owner"}}},
+			want: []string{"a@example.com: add user"},
+		},
+		{
+			name: "role change",
+			from: []platform.User{{Account: "a@example.com", Role: "member"}, {Account: "b@example.com", Role: "member"}},
+			to:   []platform.User{{Account: "a@example.com", Role: "owner"}, {Account: "b@example.com", Role: "viewer"}},
+			want: []string{`a@example.com: role change: "member" to "owner"`},
+		},
+		{
+			name: "unprivileged",
+			from: []platform.User{{Account: "a@example.com", Role: "member"}},
+			to:   []platform.User{{Account: "b@example.com", Role: "viewer"}},
+			want: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cs, err := Summary(artifact("2024-01-01", tc.from...), artifact("2024-02-01", tc.to...))
+			if err != nil {
+				t.Fatalf("Summary: %v", err)
+			}
+			got := []string{}
+			for _, c := range Privileged(cs, privileged) {
+				got = append(got, c.Entity+": "+c.Mod)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("Privileged = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMarshalCSV(t *testing.T) {
+	cs := []Change{{Kind: "vercel", ID: "vercel", Entity: "a@example.com", Mod: AddUser, Ticket: "SEC-1", Grants: []string{"admin"}}}
+
+	tests := []struct {
+		tickets bool
+		want    string
+	}{
+		{tickets: false, want: "Kind,ID,Entity,Mod,FromDate,ToDate\nvercel,vercel,a@example.com,add user,,\n"},
+		{tickets: true, want: "Kind,ID,Entity,Mod,FromDate,ToDate,Ticket\nvercel,vercel,a@example.com,add user,,,SEC-1\n"},
+	}
+	for _, tc := range tests {
+		got, err := MarshalCSV(cs, tc.tickets)
+		if err != nil {
+			t.Fatalf("MarshalCSV(%v): %v", tc.tickets, err)
+		}
+		if got != tc.want {
+			t.Errorf("MarshalCSV(%v) = %q, want %q", tc.tickets, got, tc.want)
+		}
+	}
+}
+
+func TestSummaryPermissionUsers(t *testing.T) {
+	gcp := func(date string, users map[string]platform.User) platform.Artifact {
+		return platform.Artifact{Metadata: &platform.Source{Kind: "gcp", ID: "prod", SourceDate: date}, Permissions: platform.Permissions{Users: users}}
+	}
+
+	tests := []struct {
+		name string
+		from map[string]platform.User
+		to   map[string]platform.User
+		want []string
+	}{
+		{
+			name: "renamed by uid",
+			from: map[string]platform.User{"alice": {UID: "123", Roles: []string{"roles/viewer"}}},
+			to:   map[string]platform.User{"alice.smith": {UID: "123", Roles: []string{"roles/viewer"}}},
+			want: []string{`alice.smith: renamed: "alice" to "alice.smith"`},
+		},
+		{
+			name: "added and removed",
+			from: map[string]platform.User{"alice": {UID: "123"}},
+			to:   map[string]platform.User{"bob": {UID: "456"}},
+			want: []string{"bob: add user", "alice: remove user"},
+		},
+		{
+			name: "unchanged",
+			from: map[string]platform.User{"alice": {}},
+			to:   map[string]platform.User{"alice": {}},
+			want: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cs, err := Summary(gcp("2024-01-01", tc.from), gcp("2024-02-01", tc.to))
+			if err != nil {
+				t.Fatalf("Summary: %v", err)
+			}
+			got := []string{}
+			for _, c := range cs {
+				got = append(got, c.Entity+": "+c.Mod)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("Summary = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenames(t *testing.T) {
+	tests := []struct {
+		name string
+		from []platform.User
+		to   []platform.User
+		want map[string]string
+	}{
+		{
+			name: "uid",
+			from: []platform.User{{Account: "alice@old.example.com", UID: "1"}},
+			to:   []platform.User{{Account: "alice@example.com", UID: "1"}},
+			want: map[string]string{"alice@old.example.com": "alice@example.com"},
+		},
+		{
+			name: "uid before sso",
+			from: []platform.User{{Account: "a", UID: "1", SSO: "x"}, {Account: "b", UID: "2", SSO: "y"}},
+			to:   []platform.User{{Account: "c", UID: "2", SSO: "x"}, {Account: "d", UID: "1", SSO: "y"}},
+			want: map[string]string{"a": "d", "b": "c"},
+		},
+		{
+			name: "sso before name",
+			from: []platform.User{{Account: "a", SSO: "x", Name: "Bob"}, {Account: "b", SSO: "y", Name: "Alice"}},
+			to:   []platform.User{{Account: "c", SSO: "x", Name: "Alice"}, {Account: "d", SSO: "y", Name: "Bob"}},
+			want: map[string]string{"a": "c", "b": "d"},
+		},
+		{
+			name: "name",
+			from: []platform.User{{Account: "alice", Name: "Alice Smith"}},
+			to:   []platform.User{{Account: "asmith", Name: "Alice Smith"}},
+			want: map[string]string{"alice": "asmith"},
+		},
+		{
+			name: "unconfigured sso",
+			from: []platform.User{{Account: "a", SSO: "NOT_CONFIGURED"}},
+			to:   []platform.User{{Account: "b", SSO: "NOT_CONFIGURED"}},
+			want: map[string]string{},
+		},
+		{
+			name: "ambiguous name",
+			from: []platform.User{{Account: "a", Name: "Sam"}, {Account: "b", Name: "Sam"}},
+			to:   []platform.User{{Account: "c", Name: "Sam"}},
+			want: map[string]string{},
+		},
+		{
+			name: "unchanged accounts are not candidates",
+			from: []platform.User{{Account: "a", UID: "1"}},
+			to:   []platform.User{{Account: "a", UID: "1"}, {Account: "b", UID: "1"}},
+			want: map[string]string{},
+		},
+	}
+
+	byAccount := func(us []platform.User) map[string]platform.User {
+		m := map[string]platform.User{}
+		for _, u := range us {
+			m[u.Account] = u
+		}
+		return m
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := renames(byAccount(tc.from), byAccount(tc.to))
+			if len(got) != len(tc.want) {
+				t.Fatalf("renames = %v, want %v", got, tc.want)
+			}
+			for old, acct := range tc.want {
+				if got[old] != acct {
+					t.Errorf("renames = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package config describes a yacls project: every source audited, and where the resulting artifacts go.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the configuration file read by "yacls run" when none is given.
+const DefaultFile = "yacls.yaml"
+
+// Config is the contents of a yacls.yaml file.
+//
+//	gcp_identity_project: corp-identity
+//	workers: 8
+//	timeout: 10m
+//	output:
+//	  dir: out/
+//	  age_recipients: [age1...]
+//	sources:
+//	  - kind: gcp
+//	    project: prod-env
+//	    timeout: 30m
+//	  - kind: github_org
+//	    input: exports/github-*.csv
+//	  - kind: slack
+//	    input: exports/slack*.csv
+type Config struct {
+	// GCPIdentityProject is the default project used for GCP Cloud Identity lookups
+	GCPIdentityProject string `yaml:"gcp_identity_project,omitempty"`
+	// Workers is the number of inputs to process concurrently
+	Workers int `yaml:"workers,omitempty"`
+	// Timeout is the default time limit for processing each source
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	Output  Output        `yaml:"output,omitempty"`
+	Sources []Source      `yaml:"sources"`
+}
+
+// Output describes where artifacts are written.
+type Output struct {
+	Dir           string   `yaml:"dir,omitempty"`
+	AgeRecipients []string `yaml:"age_recipients,omitempty"`
+}
+
+// Source is a single audited source, such as a GCP project or a GitHub organization.
+type Source struct {
+	Kind    string `yaml:"kind"`
+	Project string `yaml:"project,omitempty"`
+	// Input is a glob (as understood by filepath.Match) of files, directories or zip archives to process
+	Input              string `yaml:"input,omitempty"`
+	GCPIdentityProject string `yaml:"gcp_identity_project,omitempty"`
+	// Timeout is the time limit for processing each input of this source, overriding the default
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// String returns a short human-readable description of the source.
+func (s Source) String() string {
+	desc := s.Kind
+	if s.Project != "" {
+		desc += " (" + s.Project + ")"
+	}
+	if s.Input != "" {
+		desc += " from " + s.Input
+	}
+	return desc
+}
+
+// Parse parses a configuration file, rejecting unknown fields.
+func Parse(bs []byte) (*Config, error) {
+	c := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	if len(c.Sources) == 0 {
+		return nil, fmt.Errorf("no sources configured")
+	}
+	for i, s := range c.Sources {
+		if s.Kind == "" {
+			return nil, fmt.Errorf("source %d: kind is required", i+1)
+		}
+		if s.GCPIdentityProject == "" {
+			c.Sources[i].GCPIdentityProject = c.GCPIdentityProject
+		}
+		if s.Timeout == 0 {
+			c.Sources[i].Timeout = c.Timeout
+		}
+	}
+	return c, nil
+}
+
+// Load reads a configuration file. Relative paths within it are resolved against its directory.
+func Load(path string) (*Config, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	c, err := Parse(bs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	if c.Output.Dir != "" {
+		c.Output.Dir = resolve(dir, c.Output.Dir)
+	}
+	for i, s := range c.Sources {
+		if s.Input != "" {
+			c.Sources[i].Input = resolve(dir, s.Input)
+		}
+	}
+	return c, nil
+}
+
+func resolve(dir string, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
@@ -0,0 +1,268 @@
+// Package controls maps artifacts and findings to compliance controls, such as those of SOC 2 or ISO 27001,
+// so that evidence can be gathered per control rather than per tool.
+package controls
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"gopkg.in/yaml.v3"
+)
+
+// AnyKind tags every kind with the same controls.
+const AnyKind = "*"
+
+// Control is a single control within a compliance framework.
+type Control struct {
+	ID        string `yaml:"id"`
+	Framework string `yaml:"framework,omitempty"`
+	Title     string `yaml:"title,omitempty"`
+}
+
+// Mapping tags kinds and finding types with the IDs of the controls they serve as evidence for.
+//
+//	controls:
+//	  - id: CC6.2
+//	    framework: SOC 2
+//	    title: User registration and authorization
+//	  - id: A.5.18
+//	    framework: ISO 27001
+//	    title: Access rights
+//	kinds:
+//	  "*": [A.5.18]
+//	  gcp: [CC6.2]
+//	findings:
+//	  add user: [CC6.2, A.5.18]
+//	  unjustified: [CC6.2]
+type Mapping struct {
+	Controls []Control           `yaml:"controls"`
+	Kinds    map[string][]string `yaml:"kinds,omitempty"`
+	// Findings are keyed by compare.FindingTypes, or compare.Unjustified for changes without a justification
+	Findings map[string][]string `yaml:"findings,omitempty"`
+}
+
+// Load reads a mapping file, checking that every kind and finding type is known and every control is defined.
+func Load(path string) (*Mapping, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	m := &Mapping{}
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	if err := dec.Decode(m); err != nil {
+		return nil, fmt.Errorf("%s: decode: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+func (m *Mapping) validate() error {
+	defined := map[string]bool{}
+	for i, c := range m.Controls {
+		if c.ID == "" {
+			return fmt.Errorf("control %d: id is required", i+1)
+		}
+		if defined[c.ID] {
+			return fmt.Errorf("control %q is defined more than once", c.ID)
+		}
+		defined[c.ID] = true
+	}
+
+	check := func(what string, ids []string) error {
+		for _, id := range ids {
+			if !defined[id] {
+				return fmt.Errorf("%s: undefined control %q", what, id)
+			}
+		}
+		return nil
+	}
+
+	for kind, ids := range m.Kinds {
+		if kind != AnyKind {
+			if _, err := platform.New(kind); err != nil {
+				return fmt.Errorf("kinds: %w", err)
+			}
+		}
+		if err := check("kind "+kind, ids); err != nil {
+			return err
+		}
+	}
+	for t, ids := range m.Findings {
+		if t != compare.Unjustified && !slices.Contains(compare.FindingTypes, t) {
+			return fmt.Errorf("findings: unknown finding type %q: expected one of %q or %q", t, compare.FindingTypes, compare.Unjustified)
+		}
+		if err := check("finding "+t, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendNew appends the IDs not already within ids.
+func appendNew(ids []string, more ...string) []string {
+	for _, id := range more {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// KindControls returns the controls which artifacts of a kind serve as evidence for,
+// including those within the kind's ProcessorDescription.
+func (m *Mapping) KindControls(kind string) []string {
+	ids := appendNew(nil, m.Kinds[AnyKind]...)
+	ids = appendNew(ids, m.Kinds[kind]...)
+	if p, err := platform.New(kind); err == nil {
+		ids = appendNew(ids, p.Description().Controls...)
+	}
+	return ids
+}
+
+// ChangeControls returns the controls which a change serves as evidence for, by its finding type and justification.
+func (m *Mapping) ChangeControls(c compare.Change) []string {
+	ids := appendNew(nil, m.Findings[c.FindingType()]...)
+	if c.Ticket == compare.Unjustified {
+		ids = appendNew(ids, m.Findings[compare.Unjustified]...)
+	}
+	return ids
+}
+
+// Artifact is an artifact cited as evidence, and where to find it.
+type Artifact struct {
+	Kind       string `yaml:"kind"`
+	ID         string `yaml:"id"`
+	Name       string `yaml:"name"`
+	SourceDate string `yaml:"source_date,omitempty"`
+	Path       string `yaml:"path"`
+}
+
+// NewArtifact cites an artifact stored at path.
+func NewArtifact(path string, a *platform.Artifact) Artifact {
+	// sources without an ID are identified by their kind, as within changes
+	id := a.Metadata.ID
+	if id == "" {
+		id = a.Metadata.Kind
+	}
+	return Artifact{Kind: a.Metadata.Kind, ID: id, Name: a.Metadata.Name, SourceDate: a.Metadata.SourceDate, Path: path}
+}
+
+// Finding is a change cited as evidence.
+type Finding struct {
+	Kind    string `yaml:"kind"`
+	ID      string `yaml:"id"`
+	Account string `yaml:"account"`
+	Type    string `yaml:"type"`
+	Change  string `yaml:"change"`
+	Date    string `yaml:"date,omitempty"`
+	Ticket  string `yaml:"ticket,omitempty"`
+}
+
+func newFinding(c compare.Change) Finding {
+	date := c.ToDate
+	if date == "" {
+		date = c.FromDate
+	}
+	return Finding{Kind: c.Kind, ID: c.ID, Account: c.Entity, Type: c.FindingType(), Change: c.Mod, Date: date, Ticket: c.Ticket}
+}
+
+// Entry is the evidence gathered for a single control.
+type Entry struct {
+	Control   `yaml:",inline"`
+	Artifacts []Artifact `yaml:"artifacts"`
+	Findings  []Finding  `yaml:"findings"`
+}
+
+// Report groups artifacts and changes by the controls they serve as evidence for. Every defined control is included,
+// in the order of the mapping file, so that controls without evidence stand out. Controls named only within a
+// ProcessorDescription follow, ordered by ID.
+func Report(m *Mapping, as []Artifact, cs []compare.Change) []Entry {
+	entries := []*Entry{}
+	byID := map[string]*Entry{}
+	for _, c := range m.Controls {
+		e := &Entry{Control: c, Artifacts: []Artifact{}, Findings: []Finding{}}
+		entries = append(entries, e)
+		byID[c.ID] = e
+	}
+
+	extra := []*Entry{}
+	entry := func(id string) *Entry {
+		if e := byID[id]; e != nil {
+			return e
+		}
+		e := &Entry{Control: Control{ID: id}, Artifacts: []Artifact{}, Findings: []Finding{}}
+		extra = append(extra, e)
+		byID[id] = e
+		return e
+	}
+
+	for _, a := range as {
+		for _, id := range m.KindControls(a.Kind) {
+			e := entry(id)
+			e.Artifacts = append(e.Artifacts, a)
+		}
+	}
+	for _, c := range cs {
+		for _, id := range m.ChangeControls(c) {
+			e := entry(id)
+			e.Findings = append(e.Findings, newFinding(c))
+		}
+	}
+
+	sort.Slice(extra, func(i, j int) bool { return extra[i].ID < extra[j].ID })
+	out := []Entry{}
+	for _, e := range append(entries, extra...) {
+		out = append(out, *e)
+	}
+	return out
+}
+
+// Row is a single piece of evidence for a control, for CSV output.
+type Row struct {
+	Framework string `csv:"Framework"`
+	Control   string `csv:"Control"`
+	Title     string `csv:"Title"`
+	// Evidence is either "artifact" or "finding"
+	Evidence string `csv:"Evidence"`
+	Kind     string `csv:"Kind"`
+	ID       string `csv:"ID"`
+	Name     string `csv:"Name"`
+	Account  string `csv:"Account"`
+	Type     string `csv:"Type"`
+	Change   string `csv:"Change"`
+	Date     string `csv:"Date"`
+	Ticket   string `csv:"Ticket"`
+	Path     string `csv:"Path"`
+}
+
+// Rows flattens a report into one row per piece of evidence. Controls without evidence get a single, empty row.
+func Rows(entries []Entry) []Row {
+	rows := []Row{}
+	for _, e := range entries {
+		base := Row{Framework: e.Framework, Control: e.ID, Title: e.Title}
+		if len(e.Artifacts) == 0 && len(e.Findings) == 0 {
+			rows = append(rows, base)
+			continue
+		}
+		for _, a := range e.Artifacts {
+			r := base
+			r.Evidence, r.Kind, r.ID, r.Name, r.Date, r.Path = "artifact", a.Kind, a.ID, a.Name, a.SourceDate, a.Path
+			rows = append(rows, r)
+		}
+		for _, f := range e.Findings {
+			r := base
+			r.Evidence, r.Kind, r.ID, r.Account, r.Type, r.Change, r.Date, r.Ticket = "finding", f.Kind, f.ID, f.Account, f.Type, f.Change, f.Date, f.Ticket
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
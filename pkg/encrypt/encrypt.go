@@ -0,0 +1,121 @@
+// Package encrypt seals and opens artifacts using age (https://age-encryption.org/).
+package encrypt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// Extension is appended to the filename of encrypted artifacts.
+const Extension = ".age"
+
+// IdentityEnv is the environment variable consulted for an identity file if none is given.
+const IdentityEnv = "YACLS_AGE_IDENTITY"
+
+var (
+	binaryHeader = []byte("age-encryption.org/v1\n")
+	armorHeader  = []byte(armor.Header)
+)
+
+// ParseRecipients parses a list of age public keys, or paths to files containing them.
+func ParseRecipients(specs []string) ([]age.Recipient, error) {
+	rs := []age.Recipient{}
+	for _, s := range specs {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		if strings.HasPrefix(s, "age1") {
+			r, err := age.ParseX25519Recipient(s)
+			if err != nil {
+				return nil, fmt.Errorf("recipient %q: %w", s, err)
+			}
+			rs = append(rs, r)
+			continue
+		}
+
+		f, err := os.Open(s)
+		if err != nil {
+			return nil, fmt.Errorf("open recipients file: %w", err)
+		}
+		parsed, err := age.ParseRecipients(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("recipients file %s: %w", s, err)
+		}
+		rs = append(rs, parsed...)
+	}
+	return rs, nil
+}
+
+// LoadIdentities reads age identities from path, falling back to $YACLS_AGE_IDENTITY.
+// No identities and no error are returned if neither is set.
+func LoadIdentities(path string) ([]age.Identity, error) {
+	if path == "" {
+		path = os.Getenv(IdentityEnv)
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open identity file: %w", err)
+	}
+	defer f.Close()
+
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("identity file %s: %w", path, err)
+	}
+	return ids, nil
+}
+
+// IsEncrypted returns true if the content appears to be an age-encrypted file.
+func IsEncrypted(bs []byte) bool {
+	return bytes.HasPrefix(bs, binaryHeader) || bytes.HasPrefix(bytes.TrimLeft(bs, " \t\r\n"), armorHeader)
+}
+
+// Encrypt seals content to the given recipients.
+func Encrypt(bs []byte, rs []age.Recipient) ([]byte, error) {
+	out := &bytes.Buffer{}
+	w, err := age.Encrypt(out, rs...)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	if _, err := w.Write(bs); err != nil {
+		return nil, fmt.Errorf("write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("close: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// Decrypt returns the plaintext of content if it is age-encrypted, or the content unchanged if not.
+func Decrypt(bs []byte, ids []age.Identity) ([]byte, error) {
+	if !IsEncrypted(bs) {
+		return bs, nil
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("content is age-encrypted, but no identity was provided (see $%s)", IdentityEnv)
+	}
+
+	var in io.Reader = bytes.NewReader(bs)
+	if !bytes.HasPrefix(bs, binaryHeader) {
+		in = armor.NewReader(bytes.NewReader(bytes.TrimLeft(bs, " \t\r\n")))
+	}
+
+	r, err := age.Decrypt(in, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return io.ReadAll(r)
+}
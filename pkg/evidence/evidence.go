@@ -0,0 +1,352 @@
+// Package evidence assembles the evidence of an audit period into a single bundle: for each system, the raw export,
+// the generated artifact, the collection steps, the changes since the prior period and the reviewers' decisions.
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"github.com/chainguard-dev/yacls/v2/pkg/controls"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"github.com/chainguard-dev/yacls/v2/pkg/review"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+	"github.com/gocarina/gocsv"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed *.tmpl
+var content embed.FS
+
+// System is the evidence for a single source.
+type System struct {
+	Key      string
+	Artifact *platform.Artifact
+	// Exports are the raw inputs the artifact was generated from, if any
+	Exports []yacls.Input
+	// Changes since the prior period, if one was given
+	Changes []compare.Change
+	// Review lists the decisions made about each account, if a campaign was given
+	Review []review.Item
+}
+
+// Dir is the directory of the bundle the system's evidence is stored within.
+func (s *System) Dir() string {
+	return strings.TrimSuffix(yacls.Filename(s.Artifact), ".yaml")
+}
+
+// ExportNames returns the path of each raw export within the bundle, in the same order as Exports.
+func (s *System) ExportNames() []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for x, i := range s.Exports {
+		name := filepath.Base(i.Path)
+		if i.Path == "" {
+			name = "export"
+		}
+		// paginated exports are often saved under the same name
+		if seen[name] {
+			name = fmt.Sprintf("%d-%s", x+1, name)
+		}
+		seen[name] = true
+		names = append(names, path.Join(s.Dir(), "export", name))
+	}
+	return names
+}
+
+// Bundle is the evidence for an audit period.
+type Bundle struct {
+	Period      string
+	GeneratedAt time.Time
+	GeneratedBy string
+	Systems     []*System
+	// Missing describes configured sources for which no input was found
+	Missing []string
+	// Changes since the prior period across every system, including those which have since been removed
+	Changes  []compare.Change
+	HasPrior bool
+	// Justified is true if the changes were annotated with the tickets which justify them
+	Justified bool
+	Campaign  *review.Campaign
+	// Controls groups the evidence by compliance control, if a mapping was given
+	Controls []controls.Entry
+}
+
+// New finalizes the results of yacls.Process for jobs into a bundle, keeping each system's raw exports alongside
+// the artifact generated from them.
+func New(period string, jobs []yacls.Job, results []*platform.Artifact) *Bundle {
+	exports := map[string][]yacls.Input{}
+	artifacts := []*platform.Artifact{}
+	for x, a := range results {
+		if a == nil {
+			continue
+		}
+		// partial exports are merged by source, so note the source before finalizing
+		key := yacls.SourceKey(a)
+		if jobs[x].Input.Content != nil {
+			exports[key] = append(exports[key], jobs[x].Input)
+		}
+		artifacts = append(artifacts, a)
+	}
+
+	b := &Bundle{Period: period, GeneratedAt: time.Now()}
+	if u, err := user.Current(); err == nil {
+		b.GeneratedBy = u.Username
+	}
+	for _, a := range yacls.Finalize(artifacts) {
+		key := yacls.SourceKey(a)
+		b.Systems = append(b.Systems, &System{Key: key, Artifact: a, Exports: exports[key]})
+	}
+	sort.Slice(b.Systems, func(i, j int) bool { return b.Systems[i].Key < b.Systems[j].Key })
+	return b
+}
+
+// SetPrior records the changes since the artifacts of the prior period, keyed by yacls.SourceKey.
+func (b *Bundle) SetPrior(prior map[string]*platform.Artifact) error {
+	current := map[string]*platform.Artifact{}
+	for _, s := range b.Systems {
+		current[s.Key] = s.Artifact
+	}
+
+	cs, err := yacls.CompareSources(prior, current)
+	if err != nil {
+		return err
+	}
+	b.Changes = cs
+	b.HasPrior = true
+
+	for _, s := range b.Systems {
+		s.Changes = []compare.Change{}
+		for _, c := range cs {
+			if c.Kind == s.Artifact.Metadata.Kind && c.ID == systemID(s.Artifact) {
+				s.Changes = append(s.Changes, c)
+			}
+		}
+	}
+	return nil
+}
+
+func systemID(a *platform.Artifact) string {
+	if a.Metadata.ID == "" {
+		return a.Metadata.Kind
+	}
+	return a.Metadata.ID
+}
+
+// SetCampaign records the decisions and sign-offs of an access review campaign.
+func (b *Bundle) SetCampaign(c *review.Campaign) {
+	b.Campaign = c
+	for _, s := range b.Systems {
+		s.Review = []review.Item{}
+		for _, i := range c.Items {
+			if i.Kind == s.Artifact.Metadata.Kind && i.ID == systemID(s.Artifact) {
+				s.Review = append(s.Review, i)
+			}
+		}
+	}
+}
+
+// SetControls groups the artifacts and changes within the bundle by the controls they serve as evidence for.
+// Call it after SetPrior, so that changes are cited too.
+func (b *Bundle) SetControls(m *controls.Mapping) {
+	as := []controls.Artifact{}
+	for _, s := range b.Systems {
+		as = append(as, controls.NewArtifact(path.Join(s.Dir(), yacls.Filename(s.Artifact)), s.Artifact))
+	}
+	b.Controls = controls.Report(m, as, b.Changes)
+}
+
+// File is an entry within the manifest of a bundle.
+type File struct {
+	Path   string `yaml:"path"`
+	SHA256 string `yaml:"sha256"`
+	Bytes  int    `yaml:"bytes"`
+}
+
+// Manifest describes the contents of a bundle, so that its integrity may be verified.
+type Manifest struct {
+	Period      string    `yaml:"period"`
+	GeneratedAt time.Time `yaml:"generated_at"`
+	GeneratedBy string    `yaml:"generated_by"`
+	Files       []File    `yaml:"files"`
+}
+
+// bundleWriter adds files to a zip archive under a single directory, remembering their checksums.
+type bundleWriter struct {
+	zw       *zip.Writer
+	root     string
+	modified time.Time
+	files    []File
+}
+
+func (w *bundleWriter) add(name string, bs []byte) error {
+	f, err := w.zw.CreateHeader(&zip.FileHeader{Name: path.Join(w.root, name), Method: zip.Deflate, Modified: w.modified})
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if _, err := f.Write(bs); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	sum := sha256.Sum256(bs)
+	w.files = append(w.files, File{Path: name, SHA256: hex.EncodeToString(sum[:]), Bytes: len(bs)})
+	return nil
+}
+
+// addChanges stores changes as CSV, with a Ticket column only if they were justified.
+func (w *bundleWriter) addChanges(name string, cs []compare.Change, justified bool) error {
+	s, err := compare.MarshalCSV(cs, justified)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", name, err)
+	}
+	return w.add(name, []byte(s))
+}
+
+func (w *bundleWriter) addCSV(name string, rows any) error {
+	s, err := gocsv.MarshalString(rows)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", name, err)
+	}
+	return w.add(name, []byte(s))
+}
+
+// Write stores the bundle as a zip archive, with every file under a directory named after the period.
+// Alongside the evidence are an index.html to browse it, a manifest.yaml and a SHA256SUMS file.
+func (b *Bundle) Write(out io.Writer) error {
+	zw := zip.NewWriter(out)
+	w := &bundleWriter{zw: zw, root: b.Period, modified: b.GeneratedAt}
+
+	for _, s := range b.Systems {
+		bs, err := yacls.Encode(s.Artifact)
+		if err != nil {
+			return err
+		}
+		if err := w.add(path.Join(s.Dir(), yacls.Filename(s.Artifact)), bs); err != nil {
+			return err
+		}
+		if err := w.add(path.Join(s.Dir(), "steps.md"), steps(s.Artifact)); err != nil {
+			return err
+		}
+
+		for x, name := range s.ExportNames() {
+			if err := w.add(name, s.Exports[x].Content); err != nil {
+				return err
+			}
+		}
+
+		if s.Changes != nil {
+			if err := w.addChanges(path.Join(s.Dir(), "changes.csv"), s.Changes, b.Justified); err != nil {
+				return err
+			}
+		}
+		if s.Review != nil {
+			if err := w.addCSV(path.Join(s.Dir(), "review.csv"), &s.Review); err != nil {
+				return err
+			}
+		}
+	}
+
+	if b.HasPrior {
+		if err := w.addChanges("changes.csv", b.Changes, b.Justified); err != nil {
+			return err
+		}
+	}
+	if b.Campaign != nil {
+		bs, err := yaml.Marshal(b.Campaign)
+		if err != nil {
+			return fmt.Errorf("encode campaign: %w", err)
+		}
+		if err := w.add("review.yaml", bs); err != nil {
+			return err
+		}
+	}
+
+	if b.Controls != nil {
+		bs, err := yaml.Marshal(b.Controls)
+		if err != nil {
+			return fmt.Errorf("encode controls: %w", err)
+		}
+		if err := w.add("controls.yaml", bs); err != nil {
+			return err
+		}
+		rows := controls.Rows(b.Controls)
+		if err := w.addCSV("controls.csv", &rows); err != nil {
+			return err
+		}
+	}
+
+	index, err := b.index(w.files)
+	if err != nil {
+		return err
+	}
+	if err := w.add("index.html", index); err != nil {
+		return err
+	}
+
+	bs, err := yaml.Marshal(Manifest{Period: b.Period, GeneratedAt: b.GeneratedAt, GeneratedBy: b.GeneratedBy, Files: w.files})
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := w.add("manifest.yaml", bs); err != nil {
+		return err
+	}
+
+	// verify with: sha256sum -c SHA256SUMS
+	sums := &strings.Builder{}
+	for _, f := range w.files {
+		fmt.Fprintf(sums, "%s  %s\n", f.SHA256, f.Path)
+	}
+	if err := w.add("SHA256SUMS", []byte(sums.String())); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
+
+// steps describes how an artifact was collected, in Markdown.
+func steps(a *platform.Artifact) []byte {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# %s\n\n", a.Metadata.Name)
+	fmt.Fprintf(b, "Exported %s, generated %s by %s.\n\n", a.Metadata.SourceDate, a.Metadata.GeneratedAt.Format(time.RFC3339), a.Metadata.GeneratedBy)
+	for x, s := range a.Metadata.Process {
+		fmt.Fprintf(b, "%d. %s\n", x+1, s)
+	}
+	return []byte(b.String())
+}
+
+func (b *Bundle) index(files []File) ([]byte, error) {
+	t, err := template.ParseFS(content, "index.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	var status []review.Status
+	if b.Campaign != nil {
+		status = b.Campaign.Status()
+	}
+
+	buf := &bytes.Buffer{}
+	data := struct {
+		*Bundle
+		Files  []File
+		Status []review.Status
+	}{Bundle: b, Files: files, Status: status}
+	if err := t.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("execute: %w", err)
+	}
+	return buf.Bytes(), nil
+}
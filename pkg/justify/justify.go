@@ -0,0 +1,168 @@
+// Package justify links access changes to the tickets which justify them.
+package justify
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule justifies every change matching all of its non-empty fields with a ticket.
+// Account and Change are patterns where "*" matches anything, such as "*@example.com" or "role change: *".
+// Patterns are compiled by Load; rules built by Exact and Entity compare them literally.
+type Rule struct {
+	Ticket  string `yaml:"ticket"`
+	Kind    string `yaml:"kind,omitempty"`
+	ID      string `yaml:"id,omitempty"`
+	Account string `yaml:"account,omitempty"`
+	Change  string `yaml:"change,omitempty"`
+	// Expires is the last date, as YYYY-MM-DD, of the changes the ticket justifies, such as the end of a temporary grant
+	Expires string `yaml:"expires,omitempty"`
+
+	accountRe *regexp.Regexp
+	changeRe  *regexp.Regexp
+}
+
+// File is the contents of a justifications file.
+//
+//	justifications:
+//	  - ticket: SEC-123
+//	    kind: vercel
+//	    account: alice@example.com
+//	    change: "role change: *"
+//	  - ticket: SEC-200
+//	    kind: gcp
+//	    id: prod-env
+//	    expires: 2024-09-30
+type File struct {
+	Justifications []Rule `yaml:"justifications"`
+}
+
+// Load reads the rules within a justifications file.
+func Load(path string) ([]Rule, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	f := &File{}
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	if err := dec.Decode(f); err != nil {
+		return nil, fmt.Errorf("%s: decode: %w", path, err)
+	}
+
+	for i := range f.Justifications {
+		r := &f.Justifications[i]
+		if r.Ticket == "" {
+			return nil, fmt.Errorf("%s: justification %d: ticket is required", path, i+1)
+		}
+		if r.Expires != "" {
+			if _, err := time.Parse(platform.SourceDateFormat, r.Expires); err != nil {
+				return nil, fmt.Errorf("%s: justification %d: expires: %w", path, i+1, err)
+			}
+		}
+		r.accountRe = glob(r.Account)
+		r.changeRe = glob(r.Change)
+	}
+	return f.Justifications, nil
+}
+
+// glob compiles a pattern where "*" matches any sequence of characters, or returns nil if it has no wildcards.
+func glob(pattern string) *regexp.Regexp {
+	if !strings.Contains(pattern, "*") {
+		return nil
+	}
+	parts := strings.Split(pattern, "*")
+	re := "^" + regexp.QuoteMeta(parts[0])
+	for _, p := range parts[1:] {
+		re += ".*" + regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile(re + "$")
+}
+
+// match returns true if s matches pattern, using its compiled form if it has one.
+func match(pattern string, re *regexp.Regexp, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	if re != nil {
+		return re.MatchString(s)
+	}
+	return pattern == s
+}
+
+// Matches returns true if the rule justifies a change.
+func (r Rule) Matches(c compare.Change) bool {
+	if r.Kind != "" && r.Kind != c.Kind {
+		return false
+	}
+	if r.ID != "" && r.ID != c.ID {
+		return false
+	}
+	if r.Expires != "" && changeDate(c) > r.Expires {
+		return false
+	}
+	return match(r.Account, r.accountRe, c.Entity) && match(r.Change, r.changeRe, c.Mod)
+}
+
+// changeDate returns the date a change was observed by, as YYYY-MM-DD.
+func changeDate(c compare.Change) string {
+	if c.ToDate != "" {
+		return c.ToDate
+	}
+	return c.FromDate
+}
+
+// Annotate sets the Ticket of each change to that of the first rule matching it, or to compare.Unjustified.
+func Annotate(cs []compare.Change, rules []Rule) {
+	for i, c := range cs {
+		cs[i].Ticket = compare.Unjustified
+		for _, r := range rules {
+			if r.Matches(c) {
+				cs[i].Ticket = r.Ticket
+				break
+			}
+		}
+	}
+}
+
+// Unjustified returns the changes which no rule justified.
+func Unjustified(cs []compare.Change) []compare.Change {
+	out := []compare.Change{}
+	for _, c := range cs {
+		if c.Ticket == compare.Unjustified {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// ticketRe finds "Ticket: SEC-123" or "Justification: SEC-123" lines within commit messages.
+var ticketRe = regexp.MustCompile(`(?im)^(?:ticket|justification):[ \t]*(\S.*?)[ \t]*$`)
+
+// Tickets returns the tickets named within a commit message, joined by commas.
+func Tickets(message string) string {
+	ts := []string{}
+	for _, m := range ticketRe.FindAllStringSubmatch(message, -1) {
+		ts = append(ts, m[1])
+	}
+	return strings.Join(ts, ",")
+}
+
+// Exact returns a rule matching only the given change.
+func Exact(c compare.Change, ticket string) Rule {
+	return Rule{Ticket: ticket, Kind: c.Kind, ID: c.ID, Account: c.Entity, Change: c.Mod}
+}
+
+// Entity returns a rule matching any change to the same account within the same source as the given change.
+func Entity(c compare.Change, ticket string) Rule {
+	return Rule{Ticket: ticket, Kind: c.Kind, ID: c.ID, Account: c.Entity}
+}
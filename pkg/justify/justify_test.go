@@ -0,0 +1,141 @@
+package justify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+)
+
+func load(t *testing.T, contents string) ([]Rule, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "justifications.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return Load(path)
+}
+
+func TestAnnotate(t *testing.T) {
+	rules, err := load(t, `
+justifications:
+  - ticket: SEC-1
+    kind: vercel
+    account: alice@example.com
+    change: "role change: *"
+  - ticket: SEC-2
+    kind: gcp
+    id: prod-env
+    expires: 2024-06-30
+  - ticket: SEC-3
+    account: "*@contractor.example.com"
+    change: add user
+`)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		c    compare.Change
+		want string
+	}{
+		{
+			name: "wildcard change",
+			c:    compare.Change{Kind: "vercel", ID: "vercel", Entity: "alice@example.com", Mod: `role change: "member" to "owner"`, ToDate: "2024-05-01"},
+			want: "SEC-1",
+		},
+		{
+			name: "other account",
+			c:    compare.Change{Kind: "vercel", ID: "vercel", Entity: "bob@example.com", Mod: `role change: "member" to "owner"`, ToDate: "2024-05-01"},
+			want: compare.Unjustified,
+		},
+		{
+			name: "before expiry",
+			c:    compare.Change{Kind: "gcp", ID: "prod-env", Entity: "carol", Mod: compare.AddUser, ToDate: "2024-06-30"},
+			want: "SEC-2",
+		},
+		{
+			name: "after expiry",
+			c:    compare.Change{Kind: "gcp", ID: "prod-env", Entity: "carol", Mod: compare.AddUser, ToDate: "2024-07-01"},
+			want: compare.Unjustified,
+		},
+		{
+			name: "other project",
+			c:    compare.Change{Kind: "gcp", ID: "staging", Entity: "carol", Mod: compare.AddUser, ToDate: "2024-05-01"},
+			want: compare.Unjustified,
+		},
+		{
+			name: "wildcard account",
+			c:    compare.Change{Kind: "slack", ID: "slack", Entity: "dan@contractor.example.com", Mod: compare.AddUser},
+			want: "SEC-3",
+		},
+		{
+			name: "literal change",
+			c:    compare.Change{Kind: "slack", ID: "slack", Entity: "dan@contractor.example.com", Mod: compare.RemoveUser},
+			want: compare.Unjustified,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := []compare.Change{tc.c}
+			Annotate(cs, rules)
+			if cs[0].Ticket != tc.want {
+				t.Errorf("Ticket = %q, want %q", cs[0].Ticket, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{name: "missing ticket", contents: "justifications:\n  - kind: vercel\n", want: "ticket is required"},
+		{name: "bad expiry", contents: "justifications:\n  - ticket: SEC-1\n    expires: 30/06/2024\n", want: "expires"},
+		{name: "unknown field", contents: "justifications:\n  - ticket: SEC-1\n    acount: a@example.com\n", want: "acount"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := load(t, tc.contents)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Load error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestExactIsLiteral(t *testing.T) {
+	c := compare.Change{Kind: "vercel", ID: "vercel", Entity: "*", Mod: compare.AddUser}
+	r := Exact(c, "SEC-1")
+	if !r.Matches(c) {
+		t.Errorf("Exact rule does not match its own change")
+	}
+	other := c
+	other.Entity = "alice@example.com"
+	if r.Matches(other) {
+		t.Errorf("Exact rule treats %q as a wildcard", c.Entity)
+	}
+}
+
+func TestTickets(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{message: "Grant owner to alice\n\nTicket: SEC-123\n", want: "SEC-123"},
+		{message: "Rotate\n\nticket: SEC-1\nJustification:  SEC-2  \n", want: "SEC-1,SEC-2"},
+		{message: "Mentions Ticket: SEC-9 mid-line", want: ""},
+	}
+	for _, tc := range tests {
+		if got := Tickets(tc.message); got != tc.want {
+			t.Errorf("Tickets(%q) = %q, want %q", tc.message, got, tc.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -29,10 +30,13 @@ func (p *OnePasswordTeam) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`^.* Team Report \d+-\d+-\d+.csv$`),
+		Schema: Schema{
+			Headers: []string{"Team Member", "Email", "Status", "Two-Factor Authentication"},
+		},
 	}
 }
 
-func (p *OnePasswordTeam) Process(c Config) (*Artifact, error) {
+func (p *OnePasswordTeam) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
@@ -2,12 +2,14 @@ package platform
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"regexp"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"k8s.io/klog/v2"
 )
 
 // Auth0Members parses the CSV file generated by the OnePassword Team page.
@@ -26,16 +28,51 @@ func (p *Auth0Members) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`Tenant Settings.html$`),
+		Schema: Schema{
+			Selectors: []SelectorRequirement{
+				{Selector: "tr td p"},
+			},
+		},
 	}
 }
 
-func (p *Auth0Members) Process(c Config) (*Artifact, error) {
+// Sniff recognizes saved Auth0 pages by their member table and a mention of "auth0".
+func (p *Auth0Members) Sniff(content []byte) float64 {
+	return markerScore(content, p.Description().Schema, "auth0")
+}
+
+func (p *Auth0Members) harSource() harSource {
+	return harSource{
+		URL: regexp.MustCompile(`manage\.auth0\.com/api/.*members`),
+		Parse: func(body []byte) ([]User, error) {
+			members := []struct {
+				Email string   `json:"email"`
+				Name  string   `json:"name"`
+				Roles []string `json:"roles"`
+			}{}
+			if err := json.Unmarshal(body, &members); err != nil {
+				return nil, err
+			}
+
+			users := []User{}
+			for _, m := range members {
+				users = append(users, User{Account: m.Email, Name: strings.TrimSpace(m.Name), Role: strings.Join(m.Roles, ", ")})
+			}
+			return users, nil
+		},
+	}
+}
+
+func (p *Auth0Members) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
 	}
 
 	a := &Artifact{Metadata: src}
+	if isHAR(src.content) {
+		return a, processHAR(a, src.content, p.harSource())
+	}
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(src.content))
 	if err != nil {
 		return nil, fmt.Errorf("document: %w", err)
@@ -48,25 +85,32 @@ func (p *Auth0Members) Process(c Config) (*Artifact, error) {
 
 		tr.Find("p").Each(func(_ int, p *goquery.Selection) {
 			attr, _ := p.Attr("class")
-			log.Printf("p=%s, attr=%s", p.Text(), attr)
+			klog.V(1).Infof("p=%s, attr=%s", p.Text(), attr)
 
 			if strings.Contains(p.Text(), "@") {
 				account, _, _ = strings.Cut(p.Text(), "(")
 				account = strings.TrimSpace(account)
-				log.Printf("account=%s", account)
+				klog.V(1).Infof("account=%s", account)
 			} else {
 				name, _, _ = strings.Cut(p.Text(), "(")
 				name = strings.TrimSpace(name)
-				log.Printf("name=%s", name)
+				klog.V(1).Infof("name=%s", name)
 			}
 		})
 
 		s := tr.Find("td").Eq(1)
 		role := strings.ToLower(s.Text())
 
-		if account != "" {
-			a.Users = append(a.Users, User{Account: account, Name: name, Role: role})
+		if account == "" {
+			if name != "" {
+				a.Warnf(tr.Text(), "skipping member %q without e-mail address", name)
+			}
+			return
+		}
+		if role == "" {
+			a.Warnf(tr.Text(), "unable to find role for %q", account)
 		}
+		a.Users = append(a.Users, User{Account: account, Name: name, Role: role})
 	})
 
 	return a, nil
@@ -2,8 +2,11 @@ package platform
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -26,15 +29,78 @@ func (p *cloudflareMembers) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`.*Cloudflare.*.html$`),
+		Schema: Schema{
+			Selectors: []SelectorRequirement{
+				{Selector: "div[role=row]", Min: 2},
+				{Selector: "div[role=row] div.c_sx"},
+				{Selector: "div[role=row] span.c_lf"},
+			},
+		},
+	}
+}
+
+// cloudflareStatus interprets the status of a member within API responses: only removed or disabled members are
+// deleted, and any status other than accepted, such as a pending invitation, is recorded as is.
+func cloudflareStatus(status string) (deleted bool, recorded string) {
+	switch status {
+	case "accepted":
+		return false, ""
+	case "removed", "disabled":
+		return true, ""
+	}
+	return false, status
+}
+
+func (p *cloudflareMembers) harSource() harSource {
+	return harSource{
+		URL: regexp.MustCompile(`api\.cloudflare\.com/client/v4/accounts/[^/]+/members`),
+		Parse: func(body []byte) ([]User, error) {
+			resp := struct {
+				Result []struct {
+					Status string `json:"status"`
+					User   struct {
+						Email     string `json:"email"`
+						TwoFactor bool   `json:"two_factor_authentication_enabled"`
+					} `json:"user"`
+					Roles []struct {
+						Name string `json:"name"`
+					} `json:"roles"`
+				} `json:"result"`
+			}{}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return nil, err
+			}
+
+			users := []User{}
+			for _, m := range resp.Result {
+				roles := []string{}
+				for _, r := range m.Roles {
+					roles = append(roles, r.Name)
+				}
+				sort.Strings(roles)
+
+				u := User{
+					Account:           m.User.Email,
+					Role:              strings.Join(roles, ", "),
+					TwoFactorDisabled: !m.User.TwoFactor,
+				}
+				u.Deleted, u.Status = cloudflareStatus(m.Status)
+				users = append(users, u)
+			}
+			return users, nil
+		},
 	}
 }
 
-func (p *cloudflareMembers) Process(c Config) (*Artifact, error) {
+func (p *cloudflareMembers) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
 	}
 	a := &Artifact{Metadata: src}
+	if isHAR(src.content) {
+		return a, processHAR(a, src.content, p.harSource())
+	}
 
 	// Load the HTML document
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(src.content))
@@ -68,6 +134,16 @@ func (p *cloudflareMembers) Process(c Config) (*Artifact, error) {
 				u.TwoFactorDisabled = true
 			}
 		})
+		if u.Account == "" {
+			// header rows contain neither
+			if u.Role != "" {
+				a.Warnf(row.Text(), "skipping member with role %q but no e-mail address", u.Role)
+			}
+			return
+		}
+		if u.Role == "" {
+			a.Warnf(row.Text(), "unable to find role for %q", u.Account)
+		}
 		a.Users = append(a.Users, u)
 	})
 
@@ -0,0 +1,576 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocarina/gocsv"
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+)
+
+// DefinitionsEnv is the environment variable consulted for a directory of processor definitions.
+const DefinitionsEnv = "YACLS_DEFINITIONS_DIR"
+
+// Definition describes a processor declaratively, for exports simple enough not to need Go.
+//
+// An example definition for a CSV export:
+//
+//	kind: zoom
+//	name: Zoom Users
+//	steps:
+//	  - Open https://zoom.us/account/user
+//	  - Click Export
+//	  - "Execute 'yacls --kind={{.Kind}} --input={{.Path}}'"
+//	filename: '^zoom_users.*\.csv$'
+//	fields:
+//	  account: Email
+//	  name: [First Name, Last Name]
+//	  role: Role
+//	rewrite:
+//	  role:
+//	    Member: ""
+//	skip:
+//	  - column: Status
+//	    equals: Deactivated
+//	bots:
+//	  - field: account
+//	    suffix: -bot
+//
+// An example definition for a saved HTML page:
+//
+//	kind: figma
+//	name: Figma Members
+//	format: html
+//	rows: tr.member-row
+//	select:
+//	  account:
+//	    selector: td.email
+//	  name:
+//	    selector: td.name
+//	  role:
+//	    selector: select option[selected]
+//	trim:
+//	  name: (you)
+//	lower: [role]
+//	rewrite:
+//	  role:
+//	    can edit: editor
+//	    can view: ""
+type Definition struct {
+	Kind     string   `yaml:"kind"`
+	Name     string   `yaml:"name"`
+	Steps    []string `yaml:"steps"`
+	Filename string   `yaml:"filename,omitempty"`
+	// Format is either "csv" (the default) or "html"
+	Format string `yaml:"format,omitempty"`
+	// IDPattern extracts Metadata.ID from the input filename using the first submatch, unless --project is given
+	IDPattern string `yaml:"id_pattern,omitempty"`
+	// Controls are compliance control IDs which artifacts of this kind serve as evidence for
+	Controls []string `yaml:"controls,omitempty"`
+
+	// Fields maps User fields (account, name, email, role, status, org, sso, project) to one or more CSV columns, joined with spaces
+	Fields map[string]Columns `yaml:"fields,omitempty"`
+	// TwoFactor marks users as having two-factor disabled unless the CSV column holds one of the enabled values
+	TwoFactor *TwoFactorRule `yaml:"two_factor,omitempty"`
+
+	// Rows is the HTML selector matching one element per user
+	Rows string `yaml:"rows,omitempty"`
+	// Select maps User fields to HTML selectors evaluated within each row
+	Select map[string]FieldSelector `yaml:"select,omitempty"`
+
+	// Cut keeps the text before a separator for a field, for example "@" to drop domains
+	Cut map[string]string `yaml:"cut,omitempty"`
+	// Trim removes a prefix or suffix from a field, for example a trailing " (you)"
+	Trim map[string]string `yaml:"trim,omitempty"`
+	// Lower lowercases fields
+	Lower []string `yaml:"lower,omitempty"`
+	// Rewrite replaces field values: role mapping tables, or hiding default values such as "member"
+	Rewrite map[string]map[string]string `yaml:"rewrite,omitempty"`
+	// Skip drops rows matching any of these conditions
+	Skip []Condition `yaml:"skip,omitempty"`
+	// Bots files rows matching any of these conditions as bots rather than users
+	Bots []Condition `yaml:"bots,omitempty"`
+
+	path       string
+	filenameRe *regexp.Regexp
+	idRe       *regexp.Regexp
+}
+
+// Columns is one or more column names; a single name may be given as a string.
+type Columns []string
+
+func (c *Columns) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*c = Columns{value.Value}
+		return nil
+	}
+	var cs []string
+	if err := value.Decode(&cs); err != nil {
+		return err
+	}
+	*c = cs
+	return nil
+}
+
+// FieldSelector extracts a value from an HTML row: the text, or an attribute, of the first non-empty match.
+type FieldSelector struct {
+	// Selector is evaluated within the row; if empty, the row itself is used
+	Selector string `yaml:"selector,omitempty"`
+	// Attr reads an attribute rather than the element text
+	Attr string `yaml:"attr,omitempty"`
+}
+
+// value returns the first non-empty value found for the selector within a row.
+func (fs FieldSelector) value(row *goquery.Selection) string {
+	sel := row
+	if fs.Selector != "" {
+		sel = row.Find(fs.Selector)
+	}
+
+	val := ""
+	sel.EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if fs.Attr != "" {
+			val = strings.TrimSpace(s.AttrOr(fs.Attr, ""))
+		} else {
+			val = strings.TrimSpace(s.Text())
+		}
+		return val == ""
+	})
+	return val
+}
+
+// TwoFactorRule describes how to tell whether two-factor authentication is enabled for a row.
+type TwoFactorRule struct {
+	Column  string   `yaml:"column"`
+	Enabled []string `yaml:"enabled"`
+}
+
+// Condition matches a row by a raw column value or a mapped field value.
+type Condition struct {
+	Column   string `yaml:"column,omitempty"`
+	Field    string `yaml:"field,omitempty"`
+	Equals   string `yaml:"equals,omitempty"`
+	Contains string `yaml:"contains,omitempty"`
+	Prefix   string `yaml:"prefix,omitempty"`
+	Suffix   string `yaml:"suffix,omitempty"`
+	Matches  string `yaml:"matches,omitempty"`
+
+	re *regexp.Regexp
+}
+
+var definitionFields = map[string]bool{
+	"account": true,
+	"name":    true,
+	"email":   true,
+	"role":    true,
+	"status":  true,
+	"org":     true,
+	"sso":     true,
+	"project": true,
+}
+
+// match returns true if the condition matches the given value.
+func (c *Condition) match(val string) bool {
+	switch {
+	case c.Equals != "":
+		return val == c.Equals
+	case c.Contains != "":
+		return strings.Contains(val, c.Contains)
+	case c.Prefix != "":
+		return strings.HasPrefix(val, c.Prefix)
+	case c.Suffix != "":
+		return strings.HasSuffix(val, c.Suffix)
+	case c.re != nil:
+		return c.re.MatchString(val)
+	default:
+		return false
+	}
+}
+
+func (c *Condition) validate() error {
+	if (c.Column == "") == (c.Field == "") {
+		return fmt.Errorf("condition must specify exactly one of column or field")
+	}
+	if c.Field != "" && !definitionFields[c.Field] {
+		return fmt.Errorf("unknown field %q", c.Field)
+	}
+	if c.Matches != "" {
+		re, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return fmt.Errorf("matches: %w", err)
+		}
+		c.re = re
+	}
+	if c.Equals == "" && c.Contains == "" && c.Prefix == "" && c.Suffix == "" && c.re == nil {
+		return fmt.Errorf("condition on %s%s has nothing to match against", c.Column, c.Field)
+	}
+	return nil
+}
+
+// validate checks a definition for mistakes, compiling any regular expressions.
+func (d *Definition) validate() error {
+	if d.Kind == "" {
+		return fmt.Errorf("kind is required")
+	}
+	if d.Name == "" {
+		d.Name = d.Kind
+	}
+	if err := ValidateSteps(d.Steps); err != nil {
+		return err
+	}
+
+	switch d.Format {
+	case "", "csv":
+		d.Format = "csv"
+		if d.Fields["account"] == nil {
+			return fmt.Errorf("fields.account is required")
+		}
+		if d.Rows != "" || d.Select != nil {
+			return fmt.Errorf("rows and select are only valid for html definitions")
+		}
+	case "html":
+		if d.Rows == "" {
+			return fmt.Errorf("rows is required for html definitions")
+		}
+		if _, ok := d.Select["account"]; !ok {
+			return fmt.Errorf("select.account is required")
+		}
+		if d.Fields != nil || d.TwoFactor != nil {
+			return fmt.Errorf("fields and two_factor are only valid for csv definitions")
+		}
+		for _, c := range append(append([]Condition{}, d.Skip...), d.Bots...) {
+			if c.Column != "" {
+				return fmt.Errorf("conditions on columns are only valid for csv definitions, use field")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown format %q, expected csv or html", d.Format)
+	}
+
+	fields := []string{}
+	for f := range d.Fields {
+		fields = append(fields, f)
+	}
+	for f := range d.Select {
+		fields = append(fields, f)
+	}
+	for f := range d.Cut {
+		fields = append(fields, f)
+	}
+	for f := range d.Trim {
+		fields = append(fields, f)
+	}
+	for f := range d.Rewrite {
+		fields = append(fields, f)
+	}
+	fields = append(fields, d.Lower...)
+	for _, f := range fields {
+		if !definitionFields[f] {
+			return fmt.Errorf("unknown field %q", f)
+		}
+	}
+	if d.Filename != "" {
+		re, err := regexp.Compile(d.Filename)
+		if err != nil {
+			return fmt.Errorf("filename: %w", err)
+		}
+		d.filenameRe = re
+	}
+	if d.IDPattern != "" {
+		re, err := regexp.Compile(d.IDPattern)
+		if err != nil {
+			return fmt.Errorf("id_pattern: %w", err)
+		}
+		d.idRe = re
+	}
+	for i := range d.Skip {
+		if err := d.Skip[i].validate(); err != nil {
+			return fmt.Errorf("skip: %w", err)
+		}
+	}
+	for i := range d.Bots {
+		if err := d.Bots[i].validate(); err != nil {
+			return fmt.Errorf("bots: %w", err)
+		}
+	}
+	return nil
+}
+
+// columns returns every column the definition reads.
+func (d *Definition) columns() []string {
+	seen := map[string]bool{}
+	for _, cs := range d.Fields {
+		for _, c := range cs {
+			seen[c] = true
+		}
+	}
+	for _, c := range append(append([]Condition{}, d.Skip...), d.Bots...) {
+		if c.Column != "" {
+			seen[c.Column] = true
+		}
+	}
+	if d.TwoFactor != nil {
+		seen[d.TwoFactor.Column] = true
+	}
+
+	cols := []string{}
+	for c := range seen {
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+// ParseDefinition parses a YAML processor definition.
+func ParseDefinition(bs []byte) (*Definition, error) {
+	d := &Definition{}
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	if err := dec.Decode(d); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// LoadDefinitions returns processors for every YAML definition found directly within dir.
+func LoadDefinitions(dir string) ([]Processor, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("readdir: %w", err)
+	}
+
+	ps := []Processor{}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		path := filepath.Join(dir, f.Name())
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+
+		d, err := ParseDefinition(bs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		d.path = path
+		klog.Infof("loaded %q processor definition from %s", d.Kind, path)
+		ps = append(ps, &DefinedProcessor{def: d})
+	}
+	return ps, nil
+}
+
+// DefinedProcessor processes inputs according to a Definition.
+type DefinedProcessor struct {
+	def *Definition
+}
+
+// NewDefinedProcessor returns a processor for a definition.
+func NewDefinedProcessor(d *Definition) (*DefinedProcessor, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	return &DefinedProcessor{def: d}, nil
+}
+
+func (p *DefinedProcessor) Description() ProcessorDescription {
+	d := ProcessorDescription{
+		Kind:     p.def.Kind,
+		Name:     p.def.Name,
+		Steps:    p.def.Steps,
+		Controls: p.def.Controls,
+	}
+
+	switch p.def.Format {
+	case "html":
+		account := p.def.Rows
+		if sel := p.def.Select["account"].Selector; sel != "" {
+			account = p.def.Rows + " " + sel
+		}
+		d.Schema = Schema{Selectors: []SelectorRequirement{{Selector: p.def.Rows}, {Selector: account}}}
+	default:
+		d.Schema = Schema{Headers: p.def.columns()}
+	}
+
+	d.MatchingFilename = p.def.filenameRe
+	return d
+}
+
+func (p *DefinedProcessor) Process(ctx context.Context, c Config) (*Artifact, error) {
+	src, err := NewSourceFromConfig(c, p)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	a := &Artifact{Metadata: src}
+	a.Metadata.ID = p.id(c)
+
+	if p.def.Format == "html" {
+		return a, p.processHTML(a, src.content)
+	}
+	return a, p.processCSV(a, src.content)
+}
+
+func (p *DefinedProcessor) processCSV(a *Artifact, content []byte) error {
+	rows, err := gocsv.CSVToMaps(bytes.NewReader(bytes.TrimPrefix(content, utf8BOM)))
+	if err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+
+	for _, row := range rows {
+		fields := map[string]string{}
+		for f, cols := range p.def.Fields {
+			vals := []string{}
+			for _, c := range cols {
+				if v := strings.TrimSpace(row[c]); v != "" {
+					vals = append(vals, v)
+				}
+			}
+			fields[f] = strings.Join(vals, " ")
+		}
+
+		u, ok := p.user(a, row, fields, strings.Join(mapValues(row), ","))
+		if !ok {
+			continue
+		}
+
+		if tf := p.def.TwoFactor; tf != nil {
+			u.TwoFactorDisabled = true
+			for _, v := range tf.Enabled {
+				if strings.EqualFold(strings.TrimSpace(row[tf.Column]), v) {
+					u.TwoFactorDisabled = false
+				}
+			}
+		}
+
+		p.add(a, row, fields, u)
+	}
+	return nil
+}
+
+func (p *DefinedProcessor) processHTML(a *Artifact, content []byte) error {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("document: %w", err)
+	}
+
+	doc.Find(p.def.Rows).Each(func(_ int, row *goquery.Selection) {
+		fields := map[string]string{}
+		for f, fs := range p.def.Select {
+			fields[f] = fs.value(row)
+		}
+
+		u, ok := p.user(a, nil, fields, row.Text())
+		if !ok {
+			return
+		}
+		p.add(a, nil, fields, u)
+	})
+	return nil
+}
+
+// user builds a User from extracted field values, returning false if the row should be skipped.
+func (p *DefinedProcessor) user(a *Artifact, row map[string]string, fields map[string]string, context string) (User, bool) {
+	p.def.transform(fields)
+	if p.def.matchAny(p.def.Skip, row, fields) {
+		return User{}, false
+	}
+
+	u := User{
+		Account: fields["account"],
+		Name:    fields["name"],
+		Email:   fields["email"],
+		Role:    fields["role"],
+		Status:  fields["status"],
+		Org:     fields["org"],
+		SSO:     fields["sso"],
+		Project: fields["project"],
+	}
+
+	if u.Account == "" {
+		a.Warnf(context, "skipping row without account")
+		return u, false
+	}
+	return u, true
+}
+
+// add files a user as a bot or a user.
+func (p *DefinedProcessor) add(a *Artifact, row map[string]string, fields map[string]string, u User) {
+	if p.def.matchAny(p.def.Bots, row, fields) {
+		a.Bots = append(a.Bots, u)
+		return
+	}
+	a.Users = append(a.Users, u)
+}
+
+// id returns the artifact ID from the project or filename.
+func (p *DefinedProcessor) id(c Config) string {
+	if c.Project != "" || p.def.idRe == nil {
+		return c.Project
+	}
+	matches := p.def.idRe.FindStringSubmatch(filepath.Base(c.Path))
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// transform applies cut, trim, lower and rewrite rules to extracted field values.
+func (d *Definition) transform(fields map[string]string) {
+	for f, sep := range d.Cut {
+		fields[f], _, _ = strings.Cut(fields[f], sep)
+		fields[f] = strings.TrimSpace(fields[f])
+	}
+	for f, t := range d.Trim {
+		fields[f] = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(fields[f], t), t))
+	}
+	for _, f := range d.Lower {
+		fields[f] = strings.ToLower(fields[f])
+	}
+	for f, rw := range d.Rewrite {
+		if to, ok := rw[fields[f]]; ok {
+			fields[f] = to
+		}
+	}
+}
+
+func (d *Definition) matchAny(conds []Condition, row map[string]string, fields map[string]string) bool {
+	for i := range conds {
+		c := &conds[i]
+		val := fields[c.Field]
+		if c.Column != "" {
+			val = row[c.Column]
+		}
+		if c.match(val) {
+			return true
+		}
+	}
+	return false
+}
+
+func mapValues(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	vals := []string{}
+	for _, k := range keys {
+		vals = append(vals, m[k])
+	}
+	return vals
+}
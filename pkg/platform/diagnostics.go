@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// maxContextLen keeps offending rows from drowning out the rest of the artifact.
+const maxContextLen = 120
+
+// Diagnostic describes an oddity found while processing a source, such as a row that could not be parsed.
+type Diagnostic struct {
+	Severity string `yaml:"severity"`
+	Message  string `yaml:"message"`
+	// Context is the offending row, selector or identity
+	Context string `yaml:"context,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Context == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s [%s]", d.Severity, d.Message, d.Context)
+}
+
+func (a *Artifact) diagnose(severity string, context string, format string, args ...any) {
+	context = strings.Join(strings.Fields(context), " ")
+	if len(context) > maxContextLen {
+		// avoid splitting a multi-byte character
+		n := maxContextLen
+		for n > 0 && !utf8.RuneStart(context[n]) {
+			n--
+		}
+		context = context[:n] + "..."
+	}
+
+	d := Diagnostic{Severity: severity, Message: fmt.Sprintf(format, args...), Context: context}
+	klog.V(1).Infof("diagnostic: %s", d)
+	a.Metadata.Diagnostics = append(a.Metadata.Diagnostics, d)
+}
+
+// Warnf records a warning about the source, such as a row that was skipped or guessed at.
+func (a *Artifact) Warnf(context string, format string, args ...any) {
+	a.diagnose(SeverityWarning, context, format, args...)
+}
+
+// Errorf records an error about the source that likely makes the artifact incomplete.
+func (a *Artifact) Errorf(context string, format string, args ...any) {
+	a.diagnose(SeverityError, context, format, args...)
+}
+
+// HasErrors returns true if any error-level diagnostics were recorded.
+func (a *Artifact) HasErrors() bool {
+	if a.Metadata == nil {
+		return false
+	}
+	for _, d := range a.Metadata.Diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -2,11 +2,13 @@ package platform
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"regexp"
 
 	"github.com/PuerkitoBio/goquery"
+	"k8s.io/klog/v2"
 )
 
 var DockerHubRoles = map[string]string{
@@ -28,16 +30,49 @@ func (p *DockerHubMembers) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`Docker.*html$`),
+		Schema: Schema{
+			Selectors: []SelectorRequirement{
+				{Selector: "table tr[data-testid=members-list-member]"},
+				{Selector: "span[data-testid=members-list-member-email]"},
+			},
+		},
 	}
 }
 
-func (p *DockerHubMembers) Process(c Config) (*Artifact, error) {
+func (p *DockerHubMembers) harSource() harSource {
+	return harSource{
+		URL: regexp.MustCompile(`hub\.docker\.com/v2/orgs/[^/]+/members`),
+		Parse: func(body []byte) ([]User, error) {
+			resp := struct {
+				Results []struct {
+					Username string `json:"username"`
+					Email    string `json:"email"`
+					Role     string `json:"role"`
+				} `json:"results"`
+			}{}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return nil, err
+			}
+
+			users := []User{}
+			for _, m := range resp.Results {
+				users = append(users, User{Account: m.Username, Email: m.Email, Role: m.Role})
+			}
+			return users, nil
+		},
+	}
+}
+
+func (p *DockerHubMembers) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
 	}
 
 	a := &Artifact{Metadata: src}
+	if isHAR(src.content) {
+		return a, processHAR(a, src.content, p.harSource())
+	}
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(src.content))
 	if err != nil {
 		return nil, fmt.Errorf("document: %w", err)
@@ -45,7 +80,7 @@ func (p *DockerHubMembers) Process(c Config) (*Artifact, error) {
 	users := map[string]User{}
 
 	tables := doc.Find("table")
-	log.Printf("found %d member tables", tables.Length())
+	klog.V(1).Infof("found %d member tables", tables.Length())
 
 	// Find the members
 	memberList := tables.First()
@@ -53,11 +88,18 @@ func (p *DockerHubMembers) Process(c Config) (*Artifact, error) {
 	memberList.Find("tr[data-testid=members-list-member]").Each(func(i int, tr *goquery.Selection) {
 		role, _ := tr.Attr("data-memberrole")
 		account, _ := tr.Attr("data-username")
-		log.Printf("found row for %s (%s)", account, role)
+		klog.V(1).Infof("found row for %s (%s)", account, role)
+		if account == "" {
+			a.Warnf(tr.Text(), "skipping member row without data-username attribute")
+			return
+		}
+		if role == "" {
+			a.Warnf(tr.Text(), "unable to find role for %q", account)
+		}
 
 		tr.Find("span[data-testid=members-list-member-email]").Each(func(i int, span *goquery.Selection) {
 			email := span.Text()
-			log.Printf("span=%s account=%s email=%s", span.Text(), account, email)
+			klog.V(1).Infof("span=%s account=%s email=%s", span.Text(), account, email)
 
 			if email != "" {
 				users[account] = User{Account: account, Email: email, Role: role}
@@ -2,15 +2,16 @@ package platform
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os/exec"
 	"slices"
 	"sort"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 	"k8s.io/klog/v2"
@@ -59,11 +60,61 @@ type gcpRoleType struct {
 	Name string `yaml:"name"`
 }
 
-type GCPMemberCache = map[string][]gcpGroupMembership
+// GCPMemberCache caches group membership lookups across processors. It is safe for concurrent use, and concurrent
+// lookups of the same group share a single call to gcloud. A nil cache is valid, and caches nothing.
+type GCPMemberCache struct {
+	mu          sync.Mutex
+	memberships map[string][]gcpGroupMembership
+	inflight    map[string]*gcpLookup
+}
+
+// gcpLookup is a group membership lookup in progress.
+type gcpLookup struct {
+	done chan struct{}
+	ms   []gcpGroupMembership
+	err  error
+}
 
 // NewGCPMemberCache returns a populated structure to be used for caching membership lookups.
-func NewGCPMemberCache() GCPMemberCache {
-	return map[string][]gcpGroupMembership{}
+func NewGCPMemberCache() *GCPMemberCache {
+	return &GCPMemberCache{memberships: map[string][]gcpGroupMembership{}, inflight: map[string]*gcpLookup{}}
+}
+
+// lookup returns the cached memberships of identity, calling fetch unless another lookup of it is in progress,
+// in which case its result is shared. Failed lookups are not cached.
+func (c *GCPMemberCache) lookup(ctx context.Context, identity string, fetch func() ([]gcpGroupMembership, error)) ([]gcpGroupMembership, error) {
+	if c == nil {
+		return fetch()
+	}
+
+	c.mu.Lock()
+	if ms, ok := c.memberships[identity]; ok {
+		c.mu.Unlock()
+		return ms, nil
+	}
+	if l := c.inflight[identity]; l != nil {
+		c.mu.Unlock()
+		select {
+		case <-l.done:
+			return l.ms, l.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	l := &gcpLookup{done: make(chan struct{})}
+	c.inflight[identity] = l
+	c.mu.Unlock()
+
+	l.ms, l.err = fetch()
+
+	c.mu.Lock()
+	if l.err == nil {
+		c.memberships[identity] = l.ms
+	}
+	delete(c.inflight, identity)
+	c.mu.Unlock()
+	close(l.done)
+	return l.ms, l.err
 }
 
 type gcpIdentity struct {
@@ -74,6 +125,8 @@ type gcpIdentity struct {
 	DisplayName string
 	Disabled    bool
 	Deleted     bool
+	// UID is the stable "?uid=" suffix GCP appends to the members of deleted accounts
+	UID string
 
 	IsServiceAccount bool
 }
@@ -103,7 +156,7 @@ func parseGCPIdentity(s string) gcpIdentity {
 	}
 
 	// extra annotation for deleted users
-	id, _, _ = strings.Cut(id, "?uid=")
+	id, uid, _ := strings.Cut(id, "?uid=")
 	name, domain, _ := strings.Cut(id, "@")
 
 	if strings.HasSuffix(domain, "gserviceaccount.com") {
@@ -116,6 +169,7 @@ func parseGCPIdentity(s string) gcpIdentity {
 		Email:    fmt.Sprintf("%s@%s", name, domain),
 		Username: name,
 		Deleted:  deleted,
+		UID:      uid,
 	}
 }
 
@@ -125,6 +179,13 @@ type serviceAccount struct {
 	Email       string
 }
 
+// gcloudCommand returns a gcloud command which is killed once ctx is done.
+func gcloudCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "gcloud", args...)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
 type organization struct {
 	DisplayName string
 }
@@ -134,8 +195,8 @@ type projectInfo struct {
 	ProjectID     string `json:"projectID"`
 }
 
-func organizationsList() ([]string, error) {
-	cmd := exec.Command("gcloud", "organizations", "list", "--format=json")
+func organizationsList(ctx context.Context) ([]string, error) {
+	cmd := gcloudCommand(ctx, "organizations", "list", "--format=json")
 	klog.Infof("executing %s", cmd)
 	stdout, err := cmd.Output()
 	if err != nil {
@@ -160,8 +221,8 @@ func organizationsList() ([]string, error) {
 	return os, nil
 }
 
-func projectNumber(project string) (string, error) {
-	cmd := exec.Command("gcloud", "projects", "describe", project, "--format=json")
+func projectNumber(ctx context.Context, project string) (string, error) {
+	cmd := gcloudCommand(ctx, "projects", "describe", project, "--format=json")
 	klog.Infof("executing %s", cmd)
 	stdout, err := cmd.Output()
 	if err != nil {
@@ -181,8 +242,8 @@ func projectNumber(project string) (string, error) {
 	return p.ProjectNumber, nil
 }
 
-func projectsByNumber() (map[string]string, error) {
-	cmd := exec.Command("gcloud", "projects", "list", "--format=json")
+func projectsByNumber(ctx context.Context) (map[string]string, error) {
+	cmd := gcloudCommand(ctx, "projects", "list", "--format=json")
 	klog.Infof("executing %s", cmd)
 	stdout, err := cmd.Output()
 	if err != nil {
@@ -207,8 +268,8 @@ func projectsByNumber() (map[string]string, error) {
 	return pbn, nil
 }
 
-func serviceAccountList(project string) (map[string]gcpIdentity, error) {
-	cmd := exec.Command("gcloud", "iam", "service-accounts", "list", "--format=json", fmt.Sprintf("--project=%s", project))
+func serviceAccountList(ctx context.Context, project string) (map[string]gcpIdentity, error) {
+	cmd := gcloudCommand(ctx, "iam", "service-accounts", "list", "--format=json", fmt.Sprintf("--project=%s", project))
 	klog.Infof("executing %s", cmd)
 	stdout, err := cmd.Output()
 	if err != nil {
@@ -238,11 +299,7 @@ func serviceAccountList(project string) (map[string]gcpIdentity, error) {
 }
 
 // expandGCPMembers expands groups into lists of users.
-func expandGCPMembers(identity string, project string, cache GCPMemberCache) ([]gcpGroupMembership, error) {
-	if cache[identity] != nil {
-		return cache[identity], nil
-	}
-
+func expandGCPMembers(ctx context.Context, identity string, project string, cache *GCPMemberCache) ([]gcpGroupMembership, error) {
 	gid := parseGCPIdentity(identity)
 	klog.Infof("might expand %+v", gid)
 
@@ -252,7 +309,14 @@ func expandGCPMembers(identity string, project string, cache GCPMemberCache) ([]
 		return []gcpGroupMembership{member}, nil
 	}
 
-	cmd := exec.Command("gcloud", "identity", "groups", "memberships", "list", fmt.Sprintf("--group-email=%s", gid.Email), fmt.Sprintf("--project=%s", project))
+	return cache.lookup(ctx, identity, func() ([]gcpGroupMembership, error) {
+		return listGCPGroupMembers(ctx, gid, project)
+	})
+}
+
+// listGCPGroupMembers calls gcloud to list the members of a group.
+func listGCPGroupMembers(ctx context.Context, gid gcpIdentity, project string) ([]gcpGroupMembership, error) {
+	cmd := gcloudCommand(ctx, "identity", "groups", "memberships", "list", fmt.Sprintf("--group-email=%s", gid.Email), fmt.Sprintf("--project=%s", project))
 	klog.Infof("executing %s", cmd)
 	stdout, err := cmd.Output()
 	if err != nil {
@@ -277,7 +341,6 @@ func expandGCPMembers(identity string, project string, cache GCPMemberCache) ([]
 		memberships = append(memberships, doc)
 	}
 
-	cache[identity] = memberships
 	return memberships, nil
 }
 
@@ -328,7 +391,7 @@ func (gr *gcpRole) String() string {
 	return fmt.Sprintf("%s (%s)", id, strings.TrimSpace(desc))
 }
 
-func gcpRoles(project string) (map[string]gcpRole, error) {
+func gcpRoles(ctx context.Context, project string) (map[string]gcpRole, error) {
 	roles := map[string]gcpRole{}
 
 	// global roles vs local
@@ -338,7 +401,7 @@ func gcpRoles(project string) (map[string]gcpRole, error) {
 	}
 
 	for _, args := range cmds {
-		cmd := exec.Command("gcloud", args...)
+		cmd := gcloudCommand(ctx, args...)
 		klog.Infof("executing %s", cmd)
 		stdout, err := cmd.Output()
 		if err != nil {
@@ -394,7 +457,7 @@ func shortName(gid gcpIdentity, orgs []string) string {
 	return shortName
 }
 
-func (p *GoogleCloudProjectIAM) Process(c Config) (*Artifact, error) {
+func (p *GoogleCloudProjectIAM) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
@@ -402,7 +465,7 @@ func (p *GoogleCloudProjectIAM) Process(c Config) (*Artifact, error) {
 	a := &Artifact{Metadata: src}
 
 	project := c.Project
-	cmd := exec.Command("gcloud", "projects", "get-ancestors-iam-policy", project)
+	cmd := gcloudCommand(ctx, "projects", "get-ancestors-iam-policy", project)
 	stdout, err := cmd.Output()
 	if err != nil {
 		if ee, ok := err.(*exec.ExitError); ok {
@@ -428,31 +491,31 @@ func (p *GoogleCloudProjectIAM) Process(c Config) (*Artifact, error) {
 
 	users := map[string]*User{}
 	memberships := map[string][]string{}
-	roles, err := gcpRoles(c.Project)
+	roles, err := gcpRoles(ctx, c.Project)
 	if err != nil {
 		return nil, fmt.Errorf("gcp roles: %v", err)
 	}
 	klog.V(1).Infof("found roles: %+v", roles)
 
-	sas, err := serviceAccountList(c.Project)
+	sas, err := serviceAccountList(ctx, c.Project)
 	if err != nil {
 		return nil, fmt.Errorf("gcp sa: %v", err)
 	}
 	klog.V(1).Infof("service account metadata: %+v", sas)
 
-	orgs, err := organizationsList()
+	orgs, err := organizationsList(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("gcp orgs: %v", err)
 	}
 
-	pnum, err := projectNumber(project)
+	pnum, err := projectNumber(ctx, project)
 	if err != nil {
 		return nil, fmt.Errorf("project number: %v", err)
 	}
 
 	klog.V(1).Infof("project number: %s - orgs: %v", pnum, orgs)
 
-	pbn, err := projectsByNumber()
+	pbn, err := projectsByNumber(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("projects by number: %v", err)
 	}
@@ -483,10 +546,10 @@ func (p *GoogleCloudProjectIAM) Process(c Config) (*Artifact, error) {
 					Name:        binding.Role,
 					Description: "Custom",
 				}
-				klog.Infof("%q not in role list", binding.Role)
+				a.Warnf(binding.Role, "role not found in role list, assuming it is a custom role")
 			}
 
-			log.Printf("binding: %+v", binding)
+			klog.V(1).Infof("binding: %+v", binding)
 			// bindMembers may be individuals or groups
 			for _, bindMember := range binding.Members {
 				if hideRoles[binding.Role] {
@@ -529,15 +592,15 @@ func (p *GoogleCloudProjectIAM) Process(c Config) (*Artifact, error) {
 					}
 					users[bindMember].Roles = append(users[bindMember].Roles, role.String())
 					memberships[key] = append(memberships[bindMember], "DIRECT")
-				case "user":
+				case "user", "deleted:user":
 					if users[bindMember] == nil {
-						u := &User{}
+						u := &User{UID: id.UID, Deleted: id.Deleted}
 						users[bindMember] = u
 					}
 					users[bindMember].Roles = append(users[bindMember].Roles, role.String())
 					memberships[key] = append(memberships[bindMember], "DIRECT")
 				case "group":
-					expanded, err := expandGCPMembers(id.Email, identityProject, c.GCPMemberCache)
+					expanded, err := expandGCPMembers(ctx, id.Email, identityProject, c.GCPMemberCache)
 					if err != nil {
 						return nil, fmt.Errorf("expand members %s: %w", bindMember, err)
 					}
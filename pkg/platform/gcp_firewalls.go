@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
@@ -46,6 +47,7 @@ func (p *GoogleCloudProjectFirewall) Description() ProcessorDescription {
 		Steps: []string{
 			"Execute 'yacls --kind={{.Kind}} --project={{.Project}}'",
 		},
+		NoInputRequired: true,
 	}
 }
 
@@ -64,7 +66,7 @@ func gcloudTargetsString(targets []gcloudTarget) string {
 	return strings.Join(ts, ",")
 }
 
-func (p *GoogleCloudProjectFirewall) Process(c Config) (*Artifact, error) {
+func (p *GoogleCloudProjectFirewall) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
@@ -73,7 +75,7 @@ func (p *GoogleCloudProjectFirewall) Process(c Config) (*Artifact, error) {
 	a.Metadata.ID = c.Project
 
 	project := c.Project
-	cmd := exec.Command("gcloud", "compute", "firewall-rules", "list", "--project", project, "--format=json")
+	cmd := gcloudCommand(ctx, "compute", "firewall-rules", "list", "--project", project, "--format=json")
 	stdout, err := cmd.Output()
 	if err != nil {
 		if ee, ok := err.(*exec.ExitError); ok {
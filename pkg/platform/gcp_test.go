@@ -0,0 +1,93 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseGCPIdentity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want gcpIdentity
+	}{
+		{
+			in:   "user:alice@example.com",
+			want: gcpIdentity{Kind: "user", Domain: "example.com", Email: "alice@example.com", Username: "alice"},
+		},
+		{
+			in:   "deleted:user:alice@example.com?uid=123456789",
+			want: gcpIdentity{Kind: "deleted:user", Domain: "example.com", Email: "alice@example.com", Username: "alice", Deleted: true, UID: "123456789"},
+		},
+		{
+			in:   "serviceAccount:deploy@prod.iam.gserviceaccount.com",
+			want: gcpIdentity{Kind: "serviceAccount", Domain: "prod.iam.gserviceaccount.com", Email: "deploy@prod.iam.gserviceaccount.com", Username: "deploy"},
+		},
+	}
+
+	for _, tc := range tests {
+		if got := parseGCPIdentity(tc.in); got != tc.want {
+			t.Errorf("parseGCPIdentity(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestGCPMemberCacheSharesLookups(t *testing.T) {
+	c := NewGCPMemberCache()
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func() ([]gcpGroupMembership, error) {
+		calls.Add(1)
+		<-release
+		return []gcpGroupMembership{{Member: gcpMember{ID: "alice@example.com"}}}, nil
+	}
+
+	const lookups = 8
+	var started, wg sync.WaitGroup
+	started.Add(lookups)
+	wg.Add(lookups)
+	for i := 0; i < lookups; i++ {
+		go func() {
+			defer wg.Done()
+			started.Done()
+			ms, err := c.lookup(ctx, "group:eng@example.com", fetch)
+			if err != nil || len(ms) != 1 {
+				t.Errorf("lookup = %v, %v", ms, err)
+			}
+		}()
+	}
+	started.Wait()
+	close(release)
+	wg.Wait()
+
+	// later lookups are served from the cache
+	if _, err := c.lookup(ctx, "group:eng@example.com", fetch); err != nil {
+		t.Errorf("cached lookup: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fetch called %d times for concurrent lookups of one group, want 1", n)
+	}
+}
+
+func TestGCPMemberCacheSkipsFailures(t *testing.T) {
+	c := NewGCPMemberCache()
+	ctx := context.Background()
+
+	calls := 0
+	fail := func() ([]gcpGroupMembership, error) {
+		calls++
+		return nil, errors.New("gcloud failed")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.lookup(ctx, "group:eng@example.com", fail); err == nil {
+			t.Errorf("lookup %d succeeded, want an error", i)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times, want failures to be retried", calls)
+	}
+}
@@ -2,6 +2,7 @@ package platform
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"regexp"
 
@@ -27,10 +28,21 @@ func (p *GhostStaff) Description() ProcessorDescription {
 			"Collect resulting .html file for analysis (the other files are not necessary)",
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
+		Schema: Schema{
+			Selectors: []SelectorRequirement{
+				{Selector: `a[href*="/staff/"]`},
+				{Selector: `a[href*="/staff/"] h3`},
+			},
+		},
 	}
 }
 
-func (p *GhostStaff) Process(c Config) (*Artifact, error) {
+// Sniff recognizes saved Ghost pages by their /staff/ profile links and a mention of "ghost".
+func (p *GhostStaff) Sniff(content []byte) float64 {
+	return markerScore(content, p.Description().Schema, "ghost")
+}
+
+func (p *GhostStaff) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
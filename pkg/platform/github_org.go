@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"regexp"
@@ -26,6 +27,10 @@ func (p *GithubOrgMembers) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`^export-.*-\d+.csv$`),
+		Schema: Schema{
+			// saml_name_id is only present for organizations with SAML configured
+			Headers: []string{"login", "name", "role", "tfa_enabled"},
+		},
 	}
 }
 
@@ -37,7 +42,7 @@ type githubMemberRecord struct {
 	SAMLNameID       string `csv:"saml_name_id"`
 }
 
-func (p *GithubOrgMembers) Process(c Config) (*Artifact, error) {
+func (p *GithubOrgMembers) Process(ctx context.Context, c Config) (*Artifact, error) {
 	org := c.Project
 	if org == "" {
 		base := filepath.Base(c.Path)
@@ -3,6 +3,7 @@ package platform
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -37,10 +38,13 @@ func (p *GoogleWorkspaceUserAudit) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`users_logs_\d+.csv$`),
+		Schema: Schema{
+			Headers: []string{"User", "User account status", "Admin status", "Admin-defined name", "2-Step verification enforcement"},
+		},
 	}
 }
 
-func (p *GoogleWorkspaceUserAudit) Process(c Config) (*Artifact, error) {
+func (p *GoogleWorkspaceUserAudit) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"sort"
@@ -25,6 +26,9 @@ func (p *GoogleWorkspaceUsers) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`User_Download_\d+_\d+.csv$`),
+		Schema: Schema{
+			Headers: []string{"Email Address [Required]", "Status [READ ONLY]", "Org Unit Path [Required]", "First Name [Required]", "Last Name [Required]", "2sv Enforced [READ ONLY]"},
+		},
 	}
 }
 
@@ -38,7 +42,7 @@ type googleWorkspaceUserRecord struct {
 	TwoFactorEnforced string `csv:"2sv Enforced [READ ONLY]"`
 }
 
-func (p *GoogleWorkspaceUsers) Process(c Config) (*Artifact, error) {
+func (p *GoogleWorkspaceUsers) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
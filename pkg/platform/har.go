@@ -0,0 +1,156 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// harFile is the subset of the HTTP Archive format (http://www.softwareishard.com/blog/har-12-spec/) yacls reads.
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// body returns the decoded response body of an entry.
+func (e harEntry) body() ([]byte, error) {
+	c := e.Response.Content
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return []byte(c.Text), nil
+}
+
+// harSource describes where a platform's members live within a HAR file, and how to read them.
+type harSource struct {
+	// URL matches the API responses listing members
+	URL *regexp.Regexp
+	// Parse turns a single matching response body into users
+	Parse func(body []byte) ([]User, error)
+}
+
+// harProcessor is implemented by processors that can build an artifact from the API responses in a HAR file.
+type harProcessor interface {
+	harSource() harSource
+}
+
+// isHAR returns true if content appears to be a HAR file rather than a saved page.
+func isHAR(content []byte) bool {
+	trimmed := bytes.TrimSpace(content)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return false
+	}
+
+	head := trimmed
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return bytes.Contains(head, []byte(`"log"`))
+}
+
+func parseHAR(content []byte) (*harFile, error) {
+	h := &harFile{}
+	if err := json.Unmarshal(content, h); err != nil {
+		return nil, fmt.Errorf("har: %w", err)
+	}
+	return h, nil
+}
+
+// harMatches returns the successful responses within a HAR file matching a source.
+func harMatches(h *harFile, hs harSource) []harEntry {
+	es := []harEntry{}
+	for _, e := range h.Log.Entries {
+		if e.Response.Status < 200 || e.Response.Status > 299 {
+			continue
+		}
+		if !strings.Contains(e.Response.Content.MimeType, "json") {
+			continue
+		}
+		if hs.URL.MatchString(e.Request.URL) {
+			es = append(es, e)
+		}
+	}
+	return es
+}
+
+// processHAR fills an artifact from the API responses recorded within a HAR file.
+// Paginated lists are spread across several responses, so users are merged by account.
+// Roles are lowercased, as APIs disagree on their case.
+func processHAR(a *Artifact, content []byte, hs harSource) error {
+	h, err := parseHAR(content)
+	if err != nil {
+		return err
+	}
+
+	entries := harMatches(h, hs)
+	if len(entries) == 0 {
+		return fmt.Errorf("no successful JSON responses matching %s found within HAR file: was the members page fully loaded while recording?", hs.URL)
+	}
+
+	seen := map[string]bool{}
+	for _, e := range entries {
+		klog.Infof("reading %s %s from HAR file", e.Request.Method, e.Request.URL)
+		body, err := e.body()
+		if err != nil {
+			a.Warnf(e.Request.URL, "unable to decode response body: %v", err)
+			continue
+		}
+
+		users, err := hs.Parse(body)
+		if err != nil {
+			a.Warnf(e.Request.URL, "unable to parse response: %v", err)
+			continue
+		}
+
+		for _, u := range users {
+			if u.Account == "" {
+				a.Warnf(e.Request.URL, "skipping member without account: %+v", u)
+				continue
+			}
+			if seen[u.Account] {
+				continue
+			}
+			seen[u.Account] = true
+			u.Role = strings.ToLower(strings.TrimSpace(u.Role))
+			a.Users = append(a.Users, u)
+		}
+	}
+	return nil
+}
+
+// sniffHAR scores a HAR file for a processor by whether it contains matching API responses.
+func sniffHAR(p Processor, content []byte) float64 {
+	hp, ok := p.(harProcessor)
+	if !ok {
+		return 0
+	}
+	h, err := parseHAR(content)
+	if err != nil {
+		return 0
+	}
+	if len(harMatches(h, hp.harSource())) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -20,6 +21,9 @@ func (p *KolideUsers) Description() ProcessorDescription {
 			"Download resulting CSV file for analysis",
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
+		Schema: Schema{
+			Headers: []string{"Name", "Email", "Permissions"},
+		},
 	}
 }
 
@@ -29,7 +33,7 @@ type kolideMemberRecord struct {
 	Permissions string `csv:"Permissions"`
 }
 
-func (p *KolideUsers) Process(c Config) (*Artifact, error) {
+func (p *KolideUsers) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
@@ -0,0 +1,176 @@
+package platform
+
+import (
+	"slices"
+
+	"k8s.io/klog/v2"
+)
+
+// MergeArtifacts combines artifacts sharing a kind and ID, such as several saved pages of a paginated members list.
+// Users are de-duplicated by account, and conflicting role values are recorded as diagnostics.
+// The order of first appearance is preserved.
+func MergeArtifacts(as []*Artifact) []*Artifact {
+	merged := []*Artifact{}
+	byKey := map[string]*Artifact{}
+
+	for _, a := range as {
+		key := a.Metadata.Kind + "\x00" + a.Metadata.ID
+		into, ok := byKey[key]
+		if !ok {
+			byKey[key] = a
+			merged = append(merged, a)
+			continue
+		}
+
+		klog.Infof("merging additional %s input for %q", a.Metadata.Kind, a.Metadata.ID)
+		mergeArtifact(into, a)
+	}
+	return merged
+}
+
+// mergeArtifact merges the contents of from into a.
+func mergeArtifact(a *Artifact, from *Artifact) {
+	if from.Metadata.SourceDate > a.Metadata.SourceDate {
+		a.Metadata.SourceDate = from.Metadata.SourceDate
+	}
+	a.Metadata.Diagnostics = append(a.Metadata.Diagnostics, from.Metadata.Diagnostics...)
+
+	a.Users = mergeUsers(a, a.Users, from.Users)
+	a.Bots = mergeUsers(a, a.Bots, from.Bots)
+	a.ServiceAccounts = mergeUsers(a, a.ServiceAccounts, from.ServiceAccounts)
+	a.Principal = mergeUsers(a, a.Principal, from.Principal)
+	a.Groups = mergeGroups(a.Groups, from.Groups)
+	a.Orgs = mergeGroups(a.Orgs, from.Orgs)
+	a.Ingress = mergeFirewallRules(a.Ingress, from.Ingress)
+	a.Egress = mergeFirewallRules(a.Egress, from.Egress)
+
+	a.Permissions.Users = mergeUserMap(a, a.Permissions.Users, from.Permissions.Users)
+	a.Permissions.ServiceAccounts = mergeUserMap(a, a.Permissions.ServiceAccounts, from.Permissions.ServiceAccounts)
+	a.Permissions.Principals = mergeUserMap(a, a.Permissions.Principals, from.Permissions.Principals)
+	for k, g := range from.Permissions.Groups {
+		if a.Permissions.Groups == nil {
+			a.Permissions.Groups = map[string]Group{}
+		}
+		a.Permissions.Groups[k] = mergeGroup(a.Permissions.Groups[k], g)
+	}
+
+	for k, v := range from.Memberships {
+		if a.Memberships == nil {
+			a.Memberships = map[string]string{}
+		}
+		if _, ok := a.Memberships[k]; !ok {
+			a.Memberships[k] = v
+		}
+	}
+}
+
+// mergeUser merges a duplicate record of a user, flagging conflicting roles.
+func mergeUser(a *Artifact, u User, dupe User) User {
+	if u.Role != dupe.Role {
+		a.Warnf(u.Account, "conflicting roles for %q across inputs: %q and %q, keeping %q", u.Account, u.Role, dupe.Role, u.Role)
+	}
+	if u.Status != dupe.Status {
+		a.Warnf(u.Account, "conflicting statuses for %q across inputs: %q and %q, keeping %q", u.Account, u.Status, dupe.Status, u.Status)
+	}
+
+	for _, r := range dupe.Roles {
+		if !slices.Contains(u.Roles, r) {
+			u.Roles = append(u.Roles, r)
+		}
+	}
+	for _, p := range dupe.Permissions {
+		if !slices.Contains(u.Permissions, p) {
+			u.Permissions = append(u.Permissions, p)
+		}
+	}
+	return u
+}
+
+func mergeUsers(a *Artifact, us []User, more []User) []User {
+	idx := map[string]int{}
+	for i, u := range us {
+		idx[u.Account] = i
+	}
+
+	for _, u := range more {
+		i, ok := idx[u.Account]
+		if !ok {
+			idx[u.Account] = len(us)
+			us = append(us, u)
+			continue
+		}
+		us[i] = mergeUser(a, us[i], u)
+	}
+	return us
+}
+
+func mergeUserMap(a *Artifact, us map[string]User, more map[string]User) map[string]User {
+	for k, u := range more {
+		if us == nil {
+			us = map[string]User{}
+		}
+		existing, ok := us[k]
+		if !ok {
+			us[k] = u
+			continue
+		}
+		if existing.Account == "" {
+			existing.Account = k
+			u.Account = k
+		}
+		us[k] = mergeUser(a, existing, u)
+	}
+	return us
+}
+
+func mergeGroup(g Group, more Group) Group {
+	if g.Name == "" {
+		g.Name = more.Name
+	}
+	if g.Description == "" {
+		g.Description = more.Description
+	}
+	for _, m := range more.Members {
+		if !slices.Contains(g.Members, m) {
+			g.Members = append(g.Members, m)
+		}
+	}
+	for _, r := range more.Roles {
+		if !slices.Contains(g.Roles, r) {
+			g.Roles = append(g.Roles, r)
+		}
+	}
+	for _, p := range more.Permissions {
+		if !slices.Contains(g.Permissions, p) {
+			g.Permissions = append(g.Permissions, p)
+		}
+	}
+	return g
+}
+
+func mergeGroups(gs []Group, more []Group) []Group {
+	idx := map[string]int{}
+	for i, g := range gs {
+		idx[g.Name] = i
+	}
+
+	for _, g := range more {
+		i, ok := idx[g.Name]
+		if !ok {
+			idx[g.Name] = len(gs)
+			gs = append(gs, g)
+			continue
+		}
+		gs[i] = mergeGroup(gs[i], g)
+	}
+	return gs
+}
+
+func mergeFirewallRules(rs []FirewallRuleMeta, more []FirewallRuleMeta) []FirewallRuleMeta {
+	for _, r := range more {
+		if !slices.ContainsFunc(rs, func(x FirewallRuleMeta) bool { return x.Name == r.Name }) {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
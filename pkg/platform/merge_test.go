@@ -0,0 +1,124 @@
+package platform
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMergeArtifacts(t *testing.T) {
+	page := func(id string, date string, users ...User) *Artifact {
+		return &Artifact{Metadata: &Source{Kind: "vercel", ID: id, SourceDate: date}, Users: users}
+	}
+
+	tests := []struct {
+		name      string
+		in        []*Artifact
+		artifacts int
+		users     []User
+		warnings  []string
+	}{
+		{
+			name:      "distinct pages",
+			in:        []*Artifact{page("team", "2024-01-01", User{Account: "a"}), page("team", "2024-01-02", User{Account: "b"})},
+			artifacts: 1,
+			users:     []User{{Account: "a"}, {Account: "b"}},
+		},
+		{
+			name:      "different ids are kept apart",
+			in:        []*Artifact{page("one", "2024-01-01", User{Account: "a"}), page("two", "2024-01-01", User{Account: "a"})},
+			artifacts: 2,
+			users:     []User{{Account: "a"}},
+		},
+		{
+			name: "duplicate users merge roles and permissions",
+			in: []*Artifact{
+				page("team", "2024-01-01", User{Account: "a", Role: "owner", Roles: []string{"x"}, Permissions: []string{"p"}}),
+				page("team", "2024-01-01", User{Account: "a", Role: "owner", Roles: []string{"x", "y"}, Permissions: []string{"q"}}),
+			},
+			artifacts: 1,
+			users:     []User{{Account: "a", Role: "owner", Roles: []string{"x", "y"}, Permissions: []string{"p", "q"}}},
+		},
+		{
+			name: "conflicting role keeps the first",
+			in: []*Artifact{
+				page("team", "2024-01-01", User{Account: "a", Role: "owner"}),
+				page("team", "2024-01-01", User{Account: "a", Role: "member"}),
+			},
+			artifacts: 1,
+			users:     []User{{Account: "a", Role: "owner"}},
+			warnings:  []string{`conflicting roles for "a" across inputs: "owner" and "member", keeping "owner"`},
+		},
+		{
+			name: "conflicting status keeps the first",
+			in: []*Artifact{
+				page("team", "2024-01-01", User{Account: "a", Status: "active"}),
+				page("team", "2024-01-01", User{Account: "a", Status: "invited"}),
+			},
+			artifacts: 1,
+			users:     []User{{Account: "a", Status: "active"}},
+			warnings:  []string{`conflicting statuses for "a" across inputs: "active" and "invited", keeping "active"`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := MergeArtifacts(tc.in)
+			if len(got) != tc.artifacts {
+				t.Fatalf("MergeArtifacts returned %d artifacts, want %d", len(got), tc.artifacts)
+			}
+			a := got[0]
+			if len(a.Users) != len(tc.users) {
+				t.Fatalf("users = %+v, want %+v", a.Users, tc.users)
+			}
+			for i, u := range tc.users {
+				g := a.Users[i]
+				if g.Account != u.Account || g.Role != u.Role || g.Status != u.Status || !slices.Equal(g.Roles, u.Roles) || !slices.Equal(g.Permissions, u.Permissions) {
+					t.Errorf("user %d = %+v, want %+v", i, g, u)
+				}
+			}
+			warnings := []string{}
+			for _, d := range a.Metadata.Diagnostics {
+				warnings = append(warnings, d.Message)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(tc.warnings, "\n") {
+				t.Errorf("warnings = %q, want %q", warnings, tc.warnings)
+			}
+		})
+	}
+}
+
+func TestMergeArtifactsPermissions(t *testing.T) {
+	a := &Artifact{
+		Metadata: &Source{Kind: "gcp", ID: "prod", SourceDate: "2024-01-01"},
+		Permissions: Permissions{
+			Users:  map[string]User{"alice": {Role: "roles/owner"}},
+			Groups: map[string]Group{"eng": {Members: []string{"alice"}}},
+		},
+	}
+	b := &Artifact{
+		Metadata: &Source{Kind: "gcp", ID: "prod", SourceDate: "2024-02-01"},
+		Permissions: Permissions{
+			Users:  map[string]User{"alice": {Role: "roles/viewer"}, "bob": {Role: "roles/viewer"}},
+			Groups: map[string]Group{"eng": {Name: "eng", Members: []string{"alice", "bob"}}},
+		},
+	}
+
+	got := MergeArtifacts([]*Artifact{a, b})
+	if len(got) != 1 {
+		t.Fatalf("MergeArtifacts returned %d artifacts, want 1", len(got))
+	}
+	m := got[0]
+	if m.Metadata.SourceDate != "2024-02-01" {
+		t.Errorf("SourceDate = %q, want the latest input's date", m.Metadata.SourceDate)
+	}
+	if m.Permissions.Users["alice"].Role != "roles/owner" || m.Permissions.Users["bob"].Role != "roles/viewer" {
+		t.Errorf("permission users = %+v", m.Permissions.Users)
+	}
+	if g := m.Permissions.Groups["eng"]; g.Name != "eng" || !slices.Equal(g.Members, []string{"alice", "bob"}) {
+		t.Errorf("group = %+v, want eng with alice and bob", g)
+	}
+	if len(m.Metadata.Diagnostics) != 1 || m.Metadata.Diagnostics[0].Context != "alice" || !strings.Contains(m.Metadata.Diagnostics[0].Message, "conflicting roles") {
+		t.Errorf("diagnostics = %v, want one role conflict for alice", m.Metadata.Diagnostics)
+	}
+}
@@ -2,8 +2,8 @@ package platform
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"html/template"
 	"io"
 	"os"
 	"os/user"
@@ -11,6 +11,8 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 )
 
@@ -88,6 +90,8 @@ type User struct {
 	Deleted           bool         `yaml:",omitempty"`
 	TwoFactorDisabled bool         `yaml:"two_factor_disabled,omitempty"`
 	SSO               string       `yaml:"sso,omitempty"`
+	// UID is a stable identifier which survives changes to the account name, such as the GCP "?uid=" suffix
+	UID string `yaml:"uid,omitempty"`
 }
 
 type Group struct {
@@ -113,6 +117,8 @@ type Source struct {
 	GeneratedAt time.Time `yaml:"generated_at"`
 	GeneratedBy string    `yaml:"generated_by"`
 	Process     []string
+	Redacted    bool         `yaml:"redacted,omitempty"`
+	Diagnostics []Diagnostic `yaml:"diagnostics,omitempty"`
 
 	content []byte
 }
@@ -130,7 +136,9 @@ func NewSourceFromConfig(c Config, p Processor) (*Source, error) {
 	}
 
 	mtime := time.Now()
-	if c.Path != "" {
+	if !c.ModTime.IsZero() {
+		mtime = c.ModTime
+	} else if c.Path != "" {
 		fi, err := os.Stat(c.Path)
 		if err != nil {
 			return nil, fmt.Errorf("stat: %w", err)
@@ -145,6 +153,21 @@ func NewSourceFromConfig(c Config, p Processor) (*Source, error) {
 	}
 
 	desc := p.Description()
+	if isHAR(content) {
+		if _, ok := p.(harProcessor); !ok {
+			return nil, fmt.Errorf("%s does not support HAR files, please provide the saved page or export instead", desc.Name)
+		}
+	} else if len(content) > 0 {
+		if err := desc.Schema.Check(content); err != nil {
+			return nil, fmt.Errorf("%s export does not match the expected format (has it changed?): %w", desc.Name, err)
+		}
+	}
+
+	steps, err := RenderSteps(desc.Steps, c)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Source{
 		GeneratedAt: time.Now(),
 		GeneratedBy: cu.Username,
@@ -152,7 +175,7 @@ func NewSourceFromConfig(c Config, p Processor) (*Source, error) {
 		content:     content,
 		Kind:        desc.Kind,
 		Name:        desc.Name,
-		Process:     renderSteps(desc.Steps, c),
+		Process:     steps,
 	}, nil
 }
 
@@ -233,6 +256,11 @@ func FinalizeArtifact(a *Artifact) {
 		}
 	*/
 
+	if len(allUsers) == 0 && len(a.Groups) == 0 && len(a.Orgs) == 0 && len(a.Ingress) == 0 && len(a.Egress) == 0 &&
+		len(a.Permissions.Users) == 0 && len(a.Permissions.ServiceAccounts) == 0 && len(a.Permissions.Principals) == 0 {
+		a.Errorf("", "no users found: the source may be empty, or its format may have changed")
+	}
+
 	a.UserCount = len(a.Users)
 	a.BotCount = len(a.Bots)
 	// a.PermissionCount = len(a.Permissions)
@@ -241,8 +269,8 @@ func FinalizeArtifact(a *Artifact) {
 	a.OrgCount = len(a.Orgs)
 }
 
-// updates {{.Path}} or {{.Project}} in a list of steps.
-func renderSteps(steps []string, c Config) []string {
+// RenderSteps fills in {{.Kind}}, {{.Path}} or {{.Project}} within a list of collection steps, using placeholders where unset.
+func RenderSteps(steps []string, c Config) ([]string, error) {
 	// Dummy output
 	if c.Path == "" {
 		c.Path = "<path>"
@@ -253,21 +281,26 @@ func renderSteps(steps []string, c Config) []string {
 
 	out := []string{}
 	for _, r := range steps {
-		r := r
 		t, err := template.New("step").Parse(r)
 		if err != nil {
-			panic(fmt.Sprintf("unable to parse step %q: %v", r, err))
+			return nil, fmt.Errorf("unable to parse step %q: %w", r, err)
 		}
 
 		bs := bytes.NewBufferString("")
 		err = t.Execute(bs, c)
 		if err != nil {
-			panic(fmt.Sprintf("unable to parse step %q: %v", r, err))
+			return nil, fmt.Errorf("unable to render step %q: %w", r, err)
 		}
 
 		out = append(out, bs.String())
 	}
-	return out
+	return out, nil
+}
+
+// ValidateSteps checks that collection steps from outside of yacls, such as definitions or plugins, can be rendered.
+func ValidateSteps(steps []string) error {
+	_, err := RenderSteps(steps, Config{})
+	return err
 }
 
 type ProcessorDescription struct {
@@ -277,50 +310,115 @@ type ProcessorDescription struct {
 	OptionalFields   []string
 	MatchingFilename *regexp.Regexp
 	Filter           map[string][]string `yaml:"filter"`
+	Schema           Schema
+	// Controls are compliance control IDs, such as SOC 2 "CC6.1", which artifacts of this kind serve as evidence for
+	Controls []string
 
 	NoInputRequired bool
 }
 
 type Config struct {
-	Path               string
-	Reader             io.Reader
+	Path   string
+	Reader io.Reader
+	// ModTime is the modification time of the input, if Path cannot be stat'd (for example, within an archive)
+	ModTime            time.Time
 	Project            string
 	Kind               string
 	GCPIdentityProject string
 
-	GCPMemberCache GCPMemberCache
+	GCPMemberCache *GCPMemberCache
 }
 
 type Processor interface {
 	Description() ProcessorDescription
-	Process(c Config) (*Artifact, error)
+	// Process builds an artifact from its input, abandoning any subprocesses once ctx is done
+	Process(ctx context.Context, c Config) (*Artifact, error)
 }
 
+// waitDelay bounds how long a cancelled subprocess may hold its output open, as children may outlive it.
+const waitDelay = 5 * time.Second
+
 func New(kind string) (Processor, error) {
-	for _, p := range Available() {
+	loadPlugins()
+	if p := lookup(kind); p != nil {
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown kind: %q", kind)
+}
+
+// lookup returns the processor already available for a kind, without discovering plugins.
+func lookup(kind string) Processor {
+	for _, p := range available() {
 		if kind == p.Description().Kind {
-			return p, nil
+			return p
 		}
 	}
-	return nil, fmt.Errorf("unknown kind: %q", kind)
+	return nil
 }
 
-func SuggestKind(path string) (string, error) {
+func suggestKindFromFilename(path string) string {
 	base := filepath.Base(path)
 	for _, p := range Available() {
 		if p.Description().MatchingFilename != nil && p.Description().MatchingFilename.MatchString(base) {
-			return p.Description().Kind, nil
+			return p.Description().Kind
 		}
 		if strings.HasPrefix(base, p.Description().Kind) {
-			return p.Description().Kind, nil
+			return p.Description().Kind
 		}
 	}
-	return "", fmt.Errorf("unable to find kind for %q", path)
+	return ""
 }
 
+func readFileIfExists(path string) ([]byte, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return bs, nil
+}
+
+var (
+	registryMu sync.Mutex
+	registered []Processor
+)
+
+// Register makes additional processors, such as those loaded from definitions, available alongside the built-in ones.
+func Register(ps ...Processor) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, p := range ps {
+		kind := p.Description().Kind
+		for _, q := range processors() {
+			if kind == q.Description().Kind {
+				return fmt.Errorf("kind %q is already registered", kind)
+			}
+		}
+
+		registered = append(registered, p)
+		sort.Slice(registered, func(i, j int) bool {
+			return registered[i].Description().Kind < registered[j].Description().Kind
+		})
+	}
+	return nil
+}
+
+// Available returns every processor: the built-in ones, followed by those registered or discovered as plugins.
 func Available() []Processor {
-	// Alphabetical
-	return []Processor{
+	loadPlugins()
+	return available()
+}
+
+func available() []Processor {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return processors()
+}
+
+// processors returns the built-in and registered processors. The caller must hold registryMu.
+func processors() []Processor {
+	// Alphabetical, followed by registered processors
+	builtin := []Processor{
 		&Auth0Members{},
 		&DockerHubMembers{},
 		&GhostStaff{},
@@ -338,6 +436,7 @@ func Available() []Processor {
 		&WebflowMembers{},
 		&cloudflareMembers{},
 	}
+	return append(builtin, registered...)
 }
 
 func AvailableKinds() []string {
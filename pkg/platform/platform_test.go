@@ -0,0 +1,83 @@
+package platform
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderSteps(t *testing.T) {
+	steps := []string{
+		"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
+		"Open https://console.cloud.google.com/iam-admin/iam?project={{.Project}}&x=1",
+	}
+
+	tests := []struct {
+		name string
+		c    Config
+		want []string
+	}{
+		{
+			name: "placeholders",
+			c:    Config{Kind: "slack"},
+			want: []string{"Execute 'yacls --kind=slack --input=<path>'", "Open https://console.cloud.google.com/iam-admin/iam?project=<project>&x=1"},
+		},
+		{
+			name: "literal values",
+			c:    Config{Kind: "slack", Path: "/tmp/<x>.csv", Project: "a&b"},
+			want: []string{"Execute 'yacls --kind=slack --input=/tmp/<x>.csv'", "Open https://console.cloud.google.com/iam-admin/iam?project=a&b&x=1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := RenderSteps(steps, tc.c)
+			if err != nil {
+				t.Fatalf("RenderSteps: %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("RenderSteps = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateSteps(t *testing.T) {
+	if err := ValidateSteps([]string{"Open {{.Path"}); err == nil {
+		t.Errorf("ValidateSteps accepted an unterminated action")
+	}
+	if err := ValidateSteps([]string{"Open {{.Nope}}"}); err == nil {
+		t.Errorf("ValidateSteps accepted an unknown field")
+	}
+}
+
+func TestRegisterConcurrently(t *testing.T) {
+	const attempts = 8
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		registered = slices.DeleteFunc(registered, func(p Processor) bool { return p.Description().Kind == "test_concurrent" })
+	})
+
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			p, err := NewDefinedProcessor(&Definition{Kind: "test_concurrent", Fields: map[string]Columns{"account": {"Email"}}})
+			if err != nil {
+				errs <- err
+				return
+			}
+			errs <- Register(p)
+		}()
+	}
+
+	registered := 0
+	for i := 0; i < attempts; i++ {
+		if err := <-errs; err == nil {
+			registered++
+		}
+	}
+	if registered != 1 {
+		t.Errorf("registered the same kind %d times, want once", registered)
+	}
+}
@@ -0,0 +1,229 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// PluginPrefix is the filename prefix of external processor executables.
+	PluginPrefix = "yacls-processor-"
+	// PluginsEnv is the environment variable consulted for an additional plugin directory.
+	PluginsEnv = "YACLS_PLUGINS_DIR"
+
+	// describeTimeout bounds how long a plugin may take to describe itself
+	describeTimeout = 30 * time.Second
+)
+
+// PluginRequest is written as JSON to the standard input of a plugin invoked with "process".
+type PluginRequest struct {
+	Config PluginConfig
+	// Input is the content of the input file, base64-encoded within JSON
+	Input []byte
+}
+
+// PluginConfig is the subset of Config that can be passed to a plugin.
+type PluginConfig struct {
+	Path               string
+	Project            string
+	Kind               string
+	GCPIdentityProject string
+}
+
+// PluginProcessor runs an external executable implementing the processor protocol:
+//
+//   - "<executable> describe" writes a JSON ProcessorDescription to stdout
+//   - "<executable> process" reads a JSON PluginRequest from stdin and writes a JSON Artifact to stdout
+//
+// Anything written to stderr is logged, and a non-zero exit status is treated as failure.
+type PluginProcessor struct {
+	path string
+	desc ProcessorDescription
+}
+
+// NewPluginProcessor returns a processor for the plugin executable at path, asking it to describe itself.
+func NewPluginProcessor(path string) (*PluginProcessor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+	defer cancel()
+
+	stdout, err := runPlugin(ctx, path, nil, "describe")
+	if err != nil {
+		return nil, err
+	}
+
+	desc := ProcessorDescription{}
+	if err := json.Unmarshal(stdout, &desc); err != nil {
+		return nil, fmt.Errorf("%s describe: decode: %w", path, err)
+	}
+
+	kind := strings.TrimPrefix(filepath.Base(path), PluginPrefix)
+	if desc.Kind == "" {
+		desc.Kind = kind
+	}
+	if desc.Kind != kind {
+		return nil, fmt.Errorf("%s describes itself as kind %q, expected %q", path, desc.Kind, kind)
+	}
+	if desc.Name == "" {
+		desc.Name = kind
+	}
+	if err := ValidateSteps(desc.Steps); err != nil {
+		return nil, fmt.Errorf("%s describe: %w", path, err)
+	}
+
+	return &PluginProcessor{path: path, desc: desc}, nil
+}
+
+func (p *PluginProcessor) Description() ProcessorDescription {
+	return p.desc
+}
+
+func (p *PluginProcessor) Process(ctx context.Context, c Config) (*Artifact, error) {
+	src, err := NewSourceFromConfig(c, p)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+
+	req, err := json.Marshal(PluginRequest{
+		Config: PluginConfig{
+			Path:               c.Path,
+			Project:            c.Project,
+			Kind:               c.Kind,
+			GCPIdentityProject: c.GCPIdentityProject,
+		},
+		Input: src.content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	stdout, err := runPlugin(ctx, p.path, req, "process")
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Artifact{}
+	if err := json.Unmarshal(stdout, a); err != nil {
+		return nil, fmt.Errorf("%s process: decode: %w", p.path, err)
+	}
+
+	// The plugin may only supply what it can know about: the rest comes from yacls
+	if a.Metadata != nil {
+		if a.Metadata.ID != "" {
+			src.ID = a.Metadata.ID
+		}
+		if a.Metadata.Name != "" {
+			src.Name = a.Metadata.Name
+		}
+		if a.Metadata.SourceDate != "" {
+			src.SourceDate = a.Metadata.SourceDate
+		}
+		src.Diagnostics = append(src.Diagnostics, a.Metadata.Diagnostics...)
+	}
+	a.Metadata = src
+
+	return a, nil
+}
+
+func runPlugin(ctx context.Context, path string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.WaitDelay = waitDelay
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	klog.Infof("executing %s", cmd)
+	stdout, err := cmd.Output()
+	if stderr.Len() > 0 {
+		klog.Infof("%s stderr: %s", filepath.Base(path), stderr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w\nstderr: %s", cmd, err, stderr)
+	}
+	return stdout, nil
+}
+
+var (
+	pluginMu      sync.Mutex
+	pluginDirs    []string
+	pluginsWanted bool
+	pluginsLoaded bool
+)
+
+// EnablePlugins arranges for plugins within dirs and $PATH to be discovered and registered the first time a kind is
+// resolved or processors are listed, so that commands which never process inputs don't run any plugin executables.
+func EnablePlugins(dirs ...string) {
+	pluginMu.Lock()
+	defer pluginMu.Unlock()
+	pluginDirs = dirs
+	pluginsWanted = true
+}
+
+// loadPlugins discovers and registers plugins once, if EnablePlugins was called.
+func loadPlugins() {
+	pluginMu.Lock()
+	defer pluginMu.Unlock()
+	if !pluginsWanted || pluginsLoaded {
+		return
+	}
+	pluginsLoaded = true
+
+	for _, p := range DiscoverPlugins(pluginDirs...) {
+		if err := Register(p); err != nil {
+			klog.Warningf("skipping plugin: %v", err)
+		}
+	}
+}
+
+// DiscoverPlugins returns processors for every yacls-processor-<kind> executable within dirs and $PATH.
+// Earlier directories take precedence, and plugins which fail to describe themselves are skipped with a warning.
+func DiscoverPlugins(dirs ...string) []Processor {
+	dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+	seen := map[string]bool{}
+	paths := []string{}
+
+	for _, d := range dirs {
+		if d == "" {
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(d, PluginPrefix+"*"))
+		if err != nil {
+			continue
+		}
+		sort.Strings(matches)
+
+		for _, m := range matches {
+			name := filepath.Base(m)
+			fi, err := os.Stat(m)
+			if err != nil || fi.IsDir() || fi.Mode()&0o111 == 0 || seen[name] {
+				continue
+			}
+			seen[name] = true
+			paths = append(paths, m)
+		}
+	}
+
+	ps := []Processor{}
+	for _, path := range paths {
+		p, err := NewPluginProcessor(path)
+		if err != nil {
+			klog.Warningf("skipping plugin: %v", err)
+			continue
+		}
+		klog.Infof("loaded %q processor plugin from %s", p.desc.Kind, path)
+		ps = append(ps, p)
+	}
+	return ps
+}
@@ -2,6 +2,8 @@ package platform
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -26,15 +28,51 @@ func (p *pulumiPeople) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`Pulumi.*.html$`),
+		Schema: Schema{
+			Selectors: []SelectorRequirement{
+				{Selector: ".cdk-row"},
+				{Selector: ".cdk-row a.login"},
+				{Selector: ".cdk-row span.ng-star-inserted"},
+			},
+		},
 	}
 }
 
-func (p *pulumiPeople) Process(c Config) (*Artifact, error) {
+func (p *pulumiPeople) harSource() harSource {
+	return harSource{
+		URL: regexp.MustCompile(`api\.pulumi\.com/api/orgs/[^/]+/members`),
+		Parse: func(body []byte) ([]User, error) {
+			resp := struct {
+				Members []struct {
+					Role string `json:"role"`
+					User struct {
+						Name  string `json:"name"`
+						Email string `json:"email"`
+					} `json:"user"`
+				} `json:"members"`
+			}{}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return nil, err
+			}
+
+			users := []User{}
+			for _, m := range resp.Members {
+				users = append(users, User{Account: m.User.Email, Name: strings.TrimSpace(m.User.Name), Role: m.Role})
+			}
+			return users, nil
+		},
+	}
+}
+
+func (p *pulumiPeople) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
 	}
 	a := &Artifact{Metadata: src}
+	if isHAR(src.content) {
+		return a, processHAR(a, src.content, p.harSource())
+	}
 
 	// Load the HTML document
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(src.content))
@@ -52,6 +90,13 @@ func (p *pulumiPeople) Process(c Config) (*Artifact, error) {
 		if strings.HasPrefix(role, "member") {
 			role = "member"
 		}
+		if email == "" {
+			a.Warnf(s.Text(), "skipping person without login")
+			return
+		}
+		if role == "" {
+			a.Warnf(s.Text(), "unable to find role for %q", email)
+		}
 		a.Users = append(a.Users, User{Account: email, Name: name, Role: role, Status: status})
 	})
 
@@ -0,0 +1,93 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Schema describes what an export must contain for a processor to make sense of it.
+// It exists to turn vendor format changes into loud failures rather than quietly incomplete artifacts.
+type Schema struct {
+	// Headers are CSV columns which must be present
+	Headers []string
+	// Selectors are HTML selectors which must match a minimum number of elements
+	Selectors []SelectorRequirement
+}
+
+// SelectorRequirement is an HTML selector which must match at least Min elements (default 1).
+type SelectorRequirement struct {
+	Selector string
+	Min      int
+}
+
+// IsEmpty returns true if the schema has no requirements.
+func (s Schema) IsEmpty() bool {
+	return len(s.Headers) == 0 && len(s.Selectors) == 0
+}
+
+// Check returns an error naming every required column or selector missing from content.
+func (s Schema) Check(content []byte) error {
+	missing := []string{}
+
+	if len(s.Headers) > 0 {
+		headers, err := csvHeaders(content)
+		if err != nil {
+			return fmt.Errorf("unable to read CSV header: %w", err)
+		}
+		for _, h := range s.Headers {
+			if !headers[h] {
+				missing = append(missing, fmt.Sprintf("missing column %q", h))
+			}
+		}
+	}
+
+	if len(s.Selectors) > 0 {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+		if err != nil {
+			return fmt.Errorf("document: %w", err)
+		}
+		for _, r := range s.Selectors {
+			want := r.Min
+			if want == 0 {
+				want = 1
+			}
+			if found := doc.Find(r.Selector).Length(); found < want {
+				missing = append(missing, fmt.Sprintf("selector %q matched %d elements, expected at least %d", r.Selector, found, want))
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return errors.New(strings.Join(missing, "; "))
+	}
+	return nil
+}
+
+// utf8BOM prefixes CSV files saved by some spreadsheet tools.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// csvHeaders returns the set of column names found in the first line of a CSV file.
+func csvHeaders(content []byte) (map[string]bool, error) {
+	content = bytes.TrimPrefix(content, utf8BOM)
+	r := csv.NewReader(bytes.NewReader(content))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	record, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]bool{}
+	for _, h := range record {
+		// Google audit exports annotate column names with the report date
+		h = googleAuditDateRegexp.ReplaceAllString(h, "")
+		headers[strings.TrimSpace(h)] = true
+	}
+	return headers, nil
+}
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -22,6 +23,9 @@ func (p *SecureframePersonnel) Description() ProcessorDescription {
 			"Download resulting CSV file for analysis",
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
+		Schema: Schema{
+			Headers: []string{"Name (email)", "Access role"},
+		},
 	}
 }
 
@@ -30,7 +34,7 @@ type secureframePersonnelRecord struct {
 	Role  string `csv:"Access role"`
 }
 
-func (p *SecureframePersonnel) Process(c Config) (*Artifact, error) {
+func (p *SecureframePersonnel) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -24,6 +25,9 @@ func (p *SlackMembers) Description() ProcessorDescription {
 
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
+		Schema: Schema{
+			Headers: []string{"username", "email", "status", "fullname", "displayname"},
+		},
 	}
 }
 
@@ -35,7 +39,7 @@ type slackMemberRecord struct {
 	DisplayName string `csv:"displayname"`
 }
 
-func (p *SlackMembers) Process(c Config) (*Artifact, error) {
+func (p *SlackMembers) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
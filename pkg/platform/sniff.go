@@ -0,0 +1,181 @@
+package platform
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"k8s.io/klog/v2"
+)
+
+// confidentScore is the minimum sniff score required to suggest a kind by content.
+const confidentScore = 0.75
+
+// sniffMargin is how far the best sniff score must lead the next, unless it fully matches, to suggest a kind by content.
+const sniffMargin = 0.2
+
+// Sniffer is implemented by processors which can recognize their input by its content
+// better than their Schema alone allows. Scores range from 0 (no idea) to 1 (certain).
+type Sniffer interface {
+	Sniff(content []byte) float64
+}
+
+// Score returns the fraction of schema requirements satisfied by content.
+func (s Schema) Score(content []byte) float64 {
+	total := len(s.Headers) + len(s.Selectors)
+	if total == 0 || len(content) == 0 {
+		return 0
+	}
+
+	found := 0
+	if len(s.Headers) > 0 {
+		headers, err := csvHeaders(content)
+		if err == nil {
+			for _, h := range s.Headers {
+				if headers[h] {
+					found++
+				}
+			}
+		}
+	}
+
+	if len(s.Selectors) > 0 && looksLikeHTML(content) {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+		if err == nil {
+			for _, r := range s.Selectors {
+				want := r.Min
+				if want == 0 {
+					want = 1
+				}
+				if doc.Find(r.Selector).Length() >= want {
+					found++
+				}
+			}
+		}
+	}
+
+	return float64(found) / float64(total)
+}
+
+// htmlMarkers are found near the start of saved pages, but not of CSV files which merely mention a tag.
+var htmlMarkers = [][]byte{[]byte("<!doctype html"), []byte("<html"), []byte("<head"), []byte("<body")}
+
+// looksLikeHTML avoids parsing CSV files as HTML documents.
+func looksLikeHTML(content []byte) bool {
+	head := content
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	head = bytes.ToLower(bytes.TrimSpace(bytes.TrimPrefix(head, utf8BOM)))
+	if !bytes.HasPrefix(head, []byte("<")) {
+		return false
+	}
+	for _, m := range htmlMarkers {
+		if bytes.Contains(head, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// markerScore scales a schema score down when a vendor-specific marker is missing. Schemas made of generic
+// selectors, such as table rows or profile links, match many saved pages equally well, so on their own they
+// cannot clear confidentScore and sniffMargin; the vendor's name is what tells such pages apart.
+func markerScore(content []byte, s Schema, marker string) float64 {
+	score := s.Score(content)
+	if !bytes.Contains(bytes.ToLower(content), []byte(strings.ToLower(marker))) {
+		score /= 2
+	}
+	return score
+}
+
+// Sniff scores how likely content is to be an input for processor p.
+func Sniff(p Processor, content []byte) float64 {
+	if isHAR(content) {
+		return sniffHAR(p, content)
+	}
+	if s, ok := p.(Sniffer); ok {
+		return s.Sniff(content)
+	}
+	return p.Description().Schema.Score(content)
+}
+
+// Recognizes returns true if a file appears to be an input for p, by filename or content.
+func Recognizes(p Processor, path string, content []byte) bool {
+	d := p.Description()
+	base := filepath.Base(path)
+	if d.MatchingFilename != nil && d.MatchingFilename.MatchString(base) {
+		return true
+	}
+	if strings.HasPrefix(base, d.Kind) {
+		return true
+	}
+	return Sniff(p, content) >= confidentScore
+}
+
+// SuggestKind suggests a kind for a file based on its name and content.
+func SuggestKind(path string) (string, error) {
+	content, err := readFileIfExists(path)
+	if err != nil {
+		return "", err
+	}
+	return SuggestKindFromContent(path, content)
+}
+
+// SuggestKindFromContent suggests a kind based on a filename, falling back to sniffing content.
+// Ambiguous content is reported as an error rather than guessed at.
+func SuggestKindFromContent(path string, content []byte) (string, error) {
+	if kind := suggestKindFromFilename(path); kind != "" {
+		return kind, nil
+	}
+
+	if len(content) == 0 {
+		return "", fmt.Errorf("unable to find kind for %q", path)
+	}
+
+	type candidate struct {
+		kind  string
+		score float64
+	}
+
+	cs := []candidate{}
+	for _, p := range Available() {
+		score := Sniff(p, content)
+		if score > 0 {
+			klog.V(1).Infof("%s sniff score for %s: %.2f", p.Description().Kind, path, score)
+			cs = append(cs, candidate{kind: p.Description().Kind, score: score})
+		}
+	}
+
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].score != cs[j].score {
+			return cs[i].score > cs[j].score
+		}
+		return cs[i].kind < cs[j].kind
+	})
+
+	if len(cs) == 0 || cs[0].score < confidentScore {
+		guess := ""
+		if len(cs) > 0 {
+			guess = fmt.Sprintf(" (closest: %s at %.0f%%)", cs[0].kind, cs[0].score*100)
+		}
+		return "", fmt.Errorf("unable to find kind for %q by filename or content%s, please pass --kind", path, guess)
+	}
+
+	// a full match stands on its own, otherwise the best score must clearly lead the next
+	if len(cs) > 1 && (cs[1].score == cs[0].score || (cs[0].score < 1 && cs[0].score-cs[1].score < sniffMargin)) {
+		near := []string{}
+		for _, c := range cs {
+			if cs[0].score-c.score < sniffMargin {
+				near = append(near, fmt.Sprintf("%s (%.0f%%)", c.kind, c.score*100))
+			}
+		}
+		return "", fmt.Errorf("content of %q is ambiguous, it may be any of: %s; please pass --kind", path, strings.Join(near, ", "))
+	}
+
+	klog.Infof("detected %s as %s by content (%.0f%% confidence)", path, cs[0].kind, cs[0].score*100)
+	return cs[0].kind, nil
+}
@@ -2,11 +2,14 @@ package platform
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"k8s.io/klog/v2"
 )
 
 // VercelMembers parses the HTML output of the Vercel Members page.
@@ -26,6 +29,12 @@ func (p *VercelMembers) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`Vercel.html|Members - Team Settings.*?html$`),
+		Schema: Schema{
+			Selectors: []SelectorRequirement{
+				{Selector: "div[data-geist-entity]"},
+				{Selector: "div[data-geist-entity] div[type=secondary]"},
+			},
+		},
 	}
 }
 
@@ -38,12 +47,39 @@ var vercelRoles = map[string]bool{
 	"contributor": true,
 }
 
-func (p *VercelMembers) Process(c Config) (*Artifact, error) {
+func (p *VercelMembers) harSource() harSource {
+	return harSource{
+		URL: regexp.MustCompile(`api\.vercel\.com/v\d+/teams/[^/]+/members`),
+		Parse: func(body []byte) ([]User, error) {
+			resp := struct {
+				Members []struct {
+					Email string `json:"email"`
+					Name  string `json:"name"`
+					Role  string `json:"role"`
+				} `json:"members"`
+			}{}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return nil, err
+			}
+
+			users := []User{}
+			for _, m := range resp.Members {
+				users = append(users, User{Account: m.Email, Name: strings.TrimSpace(m.Name), Role: m.Role})
+			}
+			return users, nil
+		},
+	}
+}
+
+func (p *VercelMembers) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
 	}
 	a := &Artifact{Metadata: src}
+	if isHAR(src.content) {
+		return a, processHAR(a, src.content, p.harSource())
+	}
 
 	// Load the HTML document
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(src.content))
@@ -53,13 +89,13 @@ func (p *VercelMembers) Process(c Config) (*Artifact, error) {
 
 	// Find the members
 	doc.Find("div[data-geist-entity]").Each(func(i int, s *goquery.Selection) {
-		fmt.Printf("attr=%s\n", s.AttrOr("data-testid", "unknown"))
+		klog.V(1).Infof("attr=%s", s.AttrOr("data-testid", "unknown"))
 
 		email := s.Find("div[type=secondary]").Text()
 		roles := []string{}
 
 		s.Find("option").Each(func(i int, opt *goquery.Selection) {
-			fmt.Printf("opt=%s\n", opt.Text())
+			klog.V(1).Infof("opt=%s", opt.Text())
 			roles = append(roles, opt.Text())
 		})
 
@@ -68,26 +104,31 @@ func (p *VercelMembers) Process(c Config) (*Artifact, error) {
 			vals := []string{}
 
 			s.Find("span").Each(func(i int, p *goquery.Selection) {
-				fmt.Printf("span=%s\n", p.Text())
+				klog.V(1).Infof("span=%s", p.Text())
 				if vercelRoles[strings.ToLower(p.Text())] {
 					vals = append(vals, strings.ToLower(p.Text()))
 				}
 			})
 
 			s.Find("p").Each(func(i int, p *goquery.Selection) {
-				fmt.Printf("p=%s\n", p.Text())
+				klog.V(1).Infof("p=%s", p.Text())
 				if vercelRoles[strings.ToLower(p.Text())] {
 					vals = append(vals, strings.ToLower(p.Text()))
 				}
 			})
 
 			if len(vals) == 0 {
-				fmt.Printf("unable to find known role for %s\n", s.Text())
+				a.Warnf(s.Text(), "unable to find known role for %q, recording role as unknown", email)
 			} else {
 				roles = append(roles, vals[0])
 			}
 		}
 
+		if email == "" {
+			a.Warnf(s.Text(), "skipping member without e-mail address")
+			return
+		}
+
 		role := "unknown"
 		if len(roles) > 0 {
 			role = roles[0]
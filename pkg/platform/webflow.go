@@ -2,12 +2,14 @@ package platform
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"regexp"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"k8s.io/klog/v2"
 )
 
 var webflowRoles = map[string]string{
@@ -29,16 +31,60 @@ func (p *WebflowMembers) Description() ProcessorDescription {
 			"Execute 'yacls --kind={{.Kind}} --input={{.Path}}'",
 		},
 		MatchingFilename: regexp.MustCompile(`ebflow.*html$`),
+		Schema: Schema{
+			Selectors: []SelectorRequirement{
+				{Selector: "table tr"},
+				{Selector: "table p[data-automation-id]"},
+			},
+		},
 	}
 }
 
-func (p *WebflowMembers) Process(c Config) (*Artifact, error) {
+// Sniff recognizes saved Webflow pages by their automation-tagged member table and a mention of "webflow".
+func (p *WebflowMembers) Sniff(content []byte) float64 {
+	return markerScore(content, p.Description().Schema, "webflow")
+}
+
+func (p *WebflowMembers) harSource() harSource {
+	return harSource{
+		URL: regexp.MustCompile(`webflow\.com/api/sites/[^/]+/(members|collaborators|permissions)`),
+		Parse: func(body []byte) ([]User, error) {
+			members := []struct {
+				Email      string `json:"email"`
+				Name       string `json:"name"`
+				Role       string `json:"role"`
+				CanPublish bool   `json:"canPublish"`
+			}{}
+			if err := json.Unmarshal(body, &members); err != nil {
+				return nil, err
+			}
+
+			users := []User{}
+			for _, m := range members {
+				u := User{Account: m.Email, Name: strings.TrimSpace(m.Name), Role: m.Role}
+				if webflowRoles[u.Role] != "" {
+					u.Role = webflowRoles[u.Role]
+				}
+				if m.CanPublish && u.Role != "admin" {
+					u.Permissions = []string{"publish"}
+				}
+				users = append(users, u)
+			}
+			return users, nil
+		},
+	}
+}
+
+func (p *WebflowMembers) Process(ctx context.Context, c Config) (*Artifact, error) {
 	src, err := NewSourceFromConfig(c, p)
 	if err != nil {
 		return nil, fmt.Errorf("source: %w", err)
 	}
 
 	a := &Artifact{Metadata: src}
+	if isHAR(src.content) {
+		return a, processHAR(a, src.content, p.harSource())
+	}
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(src.content))
 	if err != nil {
 		return nil, fmt.Errorf("document: %w", err)
@@ -58,22 +104,22 @@ func (p *WebflowMembers) Process(c Config) (*Artifact, error) {
 
 		tr.Find("p").Each(func(i int, p *goquery.Selection) {
 			attr, _ := p.Attr("data-automation-id")
-			log.Printf("p=%s, attr=%s", p.Text(), attr)
+			klog.V(1).Infof("p=%s, attr=%s", p.Text(), attr)
 
 			if strings.Contains(attr, "email") {
 				account, _, _ = strings.Cut(p.Text(), "(")
 				account = strings.TrimSpace(account)
-				log.Printf("account=%s", account)
+				klog.V(1).Infof("account=%s", account)
 			} else {
-				log.Printf("member cell=%s", p.Text())
+				klog.V(1).Infof("member cell=%s", p.Text())
 				name, _, _ = strings.Cut(p.Text(), "(")
 				name = strings.TrimSpace(name)
-				log.Printf("name=%s", name)
+				klog.V(1).Infof("name=%s", name)
 			}
 		})
 
 		tr.Find("div[data-automation-id=toggle-site-role-settings]").Each(func(i int, div *goquery.Selection) {
-			log.Printf("found role settings")
+			klog.V(1).Infof("found role settings")
 
 			div.Find("div").Each(func(i int, t *goquery.Selection) {
 				if t.Text() == "" {
@@ -87,7 +133,7 @@ func (p *WebflowMembers) Process(c Config) (*Artifact, error) {
 		})
 
 		tr.Find("input[aria-checked=true]").Each(func(i int, div *goquery.Selection) {
-			log.Printf("found checkbox")
+			klog.V(1).Infof("found checkbox")
 			perms = append(perms, "publish")
 		})
 
@@ -96,15 +142,22 @@ func (p *WebflowMembers) Process(c Config) (*Artifact, error) {
 			perms = []string{}
 		}
 
-		if account != "" {
-			users[account] = User{Account: account, Name: name, Role: role, Permissions: perms}
+		if account == "" {
+			if name != "" {
+				a.Warnf(tr.Text(), "skipping site member %q without e-mail address", name)
+			}
+			return
+		}
+		if role == "" {
+			a.Warnf(tr.Text(), "unable to find role for %q", account)
 		}
+		users[account] = User{Account: account, Name: name, Role: role, Permissions: perms}
 	})
 
 	if tables.Length() > 0 {
 		editorTable := tables.Last()
 		editorTable.Find("tr").Each(func(i int, tr *goquery.Selection) {
-			log.Printf("tr: %v", tr)
+			klog.V(1).Infof("tr: %v", tr)
 			name := ""
 			account := ""
 			perms := []string{}
@@ -115,7 +168,7 @@ func (p *WebflowMembers) Process(c Config) (*Artifact, error) {
 				if t.Children().Length() > 0 {
 					return
 				}
-				log.Printf("found editor name: %s", t.Text())
+				klog.V(1).Infof("found editor name: %s", t.Text())
 				name = t.Text()
 			})
 
@@ -124,7 +177,7 @@ func (p *WebflowMembers) Process(c Config) (*Artifact, error) {
 					return
 				}
 				account = t.Text()
-				log.Printf("found editor account: %q", account)
+				klog.V(1).Infof("found editor account: %q", account)
 			})
 
 			tr.Find("span[data-sc=SwitchLabel]").Each(func(i int, sp *goquery.Selection) {
@@ -0,0 +1,174 @@
+// Package redact rewrites personal identifiers within artifacts into stable pseudonyms.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+// emailRe finds e-mail addresses embedded within free-form text, such as diagnostics.
+var emailRe = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+
+// KeyEnv is the environment variable consulted for a redaction secret if no key file is given.
+const KeyEnv = "YACLS_REDACT_KEY"
+
+// Redactor turns identifiers into keyed pseudonyms: the same input always maps to the same token for a given secret.
+type Redactor struct {
+	key        []byte
+	keepDomain bool
+}
+
+// New returns a Redactor using the provided secret.
+func New(key []byte, keepDomain bool) (*Redactor, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("redaction key is empty")
+	}
+	return &Redactor{key: key, keepDomain: keepDomain}, nil
+}
+
+// LoadKey reads a redaction secret from path, falling back to $YACLS_REDACT_KEY.
+func LoadKey(path string) ([]byte, error) {
+	if path == "" {
+		key := os.Getenv(KeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("no redaction key file provided and $%s is unset", KeyEnv)
+		}
+		return []byte(key), nil
+	}
+
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return []byte(strings.TrimSpace(string(bs))), nil
+}
+
+func (r *Redactor) token(prefix string, s string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(s))))
+	return prefix + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// Account returns the pseudonym for an account or e-mail address.
+func (r *Redactor) Account(s string) string {
+	if s == "" || strings.HasSuffix(s, "gserviceaccount.com") {
+		return s
+	}
+
+	t := r.token("user-", s)
+	if r.keepDomain {
+		if x := strings.LastIndex(s, "@"); x > 0 {
+			t = t + s[x:]
+		}
+	}
+	return t
+}
+
+// Name returns the pseudonym for a human name.
+func (r *Redactor) Name(s string) string {
+	if s == "" {
+		return ""
+	}
+	return r.token("name-", s)
+}
+
+func (r *Redactor) user(u platform.User) platform.User {
+	u.Account = r.Account(u.Account)
+	u.Email = r.Account(u.Email)
+	u.Name = r.Name(u.Name)
+	if u.SSO != "NOT_CONFIGURED" {
+		u.SSO = r.Account(u.SSO)
+	}
+	if u.UID != "" {
+		u.UID = r.token("uid-", u.UID)
+	}
+	return u
+}
+
+func (r *Redactor) users(us []platform.User) []platform.User {
+	out := []platform.User{}
+	for _, u := range us {
+		out = append(out, r.user(u))
+	}
+	return out
+}
+
+func (r *Redactor) userMap(m map[string]platform.User) map[string]platform.User {
+	if m == nil {
+		return nil
+	}
+	out := map[string]platform.User{}
+	for k, u := range m {
+		out[r.Account(k)] = r.user(u)
+	}
+	return out
+}
+
+func (r *Redactor) groups(gs []platform.Group) []platform.Group {
+	out := []platform.Group{}
+	for _, g := range gs {
+		g.Members = r.members(g.Members)
+		out = append(out, g)
+	}
+	return out
+}
+
+func (r *Redactor) members(ms []string) []string {
+	if ms == nil {
+		return nil
+	}
+	out := []string{}
+	for _, m := range ms {
+		out = append(out, r.Account(m))
+	}
+	return out
+}
+
+// Artifact rewrites the personal identifiers within an artifact in place.
+//
+// Service accounts are left alone, as they identify workloads rather than people.
+func (r *Redactor) Artifact(a *platform.Artifact) {
+	if a.Metadata != nil {
+		a.Metadata.GeneratedBy = r.Account(a.Metadata.GeneratedBy)
+		a.Metadata.Redacted = true
+
+		// The context of a diagnostic is raw source content, which may contain anything
+		for i, d := range a.Metadata.Diagnostics {
+			d.Message = emailRe.ReplaceAllStringFunc(d.Message, r.Account)
+			d.Context = ""
+			a.Metadata.Diagnostics[i] = d
+		}
+	}
+
+	a.Users = r.users(a.Users)
+	a.Bots = r.users(a.Bots)
+	a.Principal = r.users(a.Principal)
+	a.Groups = r.groups(a.Groups)
+	a.Orgs = r.groups(a.Orgs)
+
+	for role, ms := range a.Roles {
+		a.Roles[role] = r.members(ms)
+	}
+
+	a.Permissions.Users = r.userMap(a.Permissions.Users)
+	a.Permissions.Principals = r.userMap(a.Permissions.Principals)
+	for name, g := range a.Permissions.Groups {
+		g.Members = r.members(g.Members)
+		a.Permissions.Groups[name] = g
+	}
+
+	if a.Memberships != nil {
+		ms := map[string]string{}
+		for k, v := range a.Memberships {
+			ms[r.Account(k)] = v
+		}
+		a.Memberships = ms
+	}
+}
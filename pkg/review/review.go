@@ -0,0 +1,318 @@
+// Package review runs access review campaigns: reviewers attest to each account within a set of artifacts.
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"gopkg.in/yaml.v3"
+)
+
+// Dir is the directory, alongside the artifacts, that campaign records are stored within.
+const Dir = "reviews"
+
+// Decisions a reviewer may make about an account.
+const (
+	Keep   = "keep"
+	Revoke = "revoke"
+)
+
+// Owners assigns a reviewer to each account.
+//
+//	default: security@example.com
+//	platforms:
+//	  vercel: alice@example.com
+//	  gcp/prod-env: bob@example.com
+//	managers:
+//	  carol@example.com: dave@example.com
+type Owners struct {
+	// Default reviews any account not otherwise assigned
+	Default string `yaml:"default,omitempty"`
+	// Platforms maps a kind, or a kind and ID such as gcp/prod-env, to its reviewer
+	Platforms map[string]string `yaml:"platforms,omitempty"`
+	// Managers maps an account to its reviewer, taking precedence over Platforms
+	Managers map[string]string `yaml:"managers,omitempty"`
+}
+
+// LoadOwners reads an owner mapping file.
+func LoadOwners(path string) (*Owners, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	o := &Owners{}
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	if err := dec.Decode(o); err != nil {
+		return nil, fmt.Errorf("%s: decode: %w", path, err)
+	}
+	return o, nil
+}
+
+// Reviewer returns who reviews an account within a source, or "" if nobody does.
+func (o *Owners) Reviewer(kind string, id string, account string) string {
+	if r := o.Managers[account]; r != "" {
+		return r
+	}
+	if r := o.Platforms[kind+"/"+id]; r != "" {
+		return r
+	}
+	if r := o.Platforms[kind]; r != "" {
+		return r
+	}
+	return o.Default
+}
+
+// Item is a single account awaiting, or given, a decision.
+type Item struct {
+	Reviewer string `yaml:"reviewer"`
+	Kind     string `yaml:"kind"`
+	ID       string `yaml:"id"`
+	Account  string `yaml:"account"`
+	Name     string `yaml:"name,omitempty"`
+	Role     string `yaml:"role,omitempty"`
+	Decision string `yaml:"decision,omitempty"`
+	Comment  string `yaml:"comment,omitempty"`
+}
+
+// SignOff records a reviewer's completed worksheet.
+// It is not a cryptographic signature: the reviewer is whoever the worksheet names.
+type SignOff struct {
+	Reviewer  string    `yaml:"reviewer"`
+	SignedAt  time.Time `yaml:"signed_at"`
+	Worksheet string    `yaml:"worksheet"`
+	// SHA256 is an integrity checksum of the worksheet as ingested, so the file can be matched to the record later
+	SHA256    string `yaml:"sha256"`
+	Decisions int    `yaml:"decisions"`
+}
+
+// Campaign is the record of an access review: what was reviewed, by whom, and what they decided.
+type Campaign struct {
+	Name      string    `yaml:"name"`
+	CreatedAt time.Time `yaml:"created_at"`
+	CreatedBy string    `yaml:"created_by"`
+	// Sources maps each reviewed source to the date its artifact was exported
+	Sources  map[string]string `yaml:"sources"`
+	Items    []Item            `yaml:"items"`
+	SignOffs []SignOff         `yaml:"sign_offs,omitempty"`
+}
+
+// New starts a campaign covering every user and bot within the artifacts, assigning reviewers using o.
+func New(name string, as []*platform.Artifact, o *Owners) (*Campaign, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid campaign name %q", name)
+	}
+
+	c := &Campaign{Name: name, CreatedAt: time.Now(), Sources: map[string]string{}}
+	if u, err := user.Current(); err == nil {
+		c.CreatedBy = u.Username
+	}
+
+	unowned := []string{}
+	for _, a := range as {
+		kind, id := a.Metadata.Kind, a.Metadata.ID
+		if id == "" {
+			id = kind
+		}
+		c.Sources[kind+"/"+id] = a.Metadata.SourceDate
+
+		add := func(account string, u platform.User) {
+			role := u.Role
+			if role == "" {
+				role = strings.Join(u.Roles, ", ")
+			}
+			r := o.Reviewer(kind, id, account)
+			if r == "" {
+				unowned = append(unowned, fmt.Sprintf("%s/%s: %s", kind, id, account))
+			}
+			c.Items = append(c.Items, Item{Reviewer: r, Kind: kind, ID: id, Account: account, Name: u.Name, Role: role})
+		}
+
+		for _, u := range append(append([]platform.User{}, a.Users...), a.Bots...) {
+			add(u.Account, u)
+		}
+		accts := []string{}
+		for acct := range a.Permissions.Users {
+			accts = append(accts, acct)
+		}
+		sort.Strings(accts)
+		for _, acct := range accts {
+			add(acct, a.Permissions.Users[acct])
+		}
+	}
+
+	if len(unowned) > 0 {
+		return nil, fmt.Errorf("no reviewer for %d accounts, add a default reviewer or:\n%s", len(unowned), strings.Join(unowned, "\n"))
+	}
+	return c, nil
+}
+
+// Reviewers returns everyone with accounts to review, sorted.
+func (c *Campaign) Reviewers() []string {
+	rs := []string{}
+	for _, i := range c.Items {
+		if !slices.Contains(rs, i.Reviewer) {
+			rs = append(rs, i.Reviewer)
+		}
+	}
+	sort.Strings(rs)
+	return rs
+}
+
+// Status summarizes the progress of a single reviewer.
+type Status struct {
+	Reviewer    string
+	Total       int
+	Keep        int
+	Revoke      int
+	Outstanding int
+	// SignedAt is when the reviewer's latest worksheet was ingested, if ever
+	SignedAt time.Time
+}
+
+// Status summarizes the progress of each reviewer, sorted by reviewer.
+func (c *Campaign) Status() []Status {
+	byReviewer := map[string]*Status{}
+	for _, r := range c.Reviewers() {
+		byReviewer[r] = &Status{Reviewer: r}
+	}
+	for _, i := range c.Items {
+		s := byReviewer[i.Reviewer]
+		s.Total++
+		switch i.Decision {
+		case Keep:
+			s.Keep++
+		case Revoke:
+			s.Revoke++
+		default:
+			s.Outstanding++
+		}
+	}
+	for _, so := range c.SignOffs {
+		if s := byReviewer[so.Reviewer]; s != nil && so.SignedAt.After(s.SignedAt) {
+			s.SignedAt = so.SignedAt
+		}
+	}
+
+	ss := []Status{}
+	for _, r := range c.Reviewers() {
+		ss = append(ss, *byReviewer[r])
+	}
+	return ss
+}
+
+// Path returns where the record of a campaign is stored, relative to the artifacts it covers.
+func Path(dir string, name string) string {
+	return filepath.Join(dir, Dir, name+".yaml")
+}
+
+// List returns the names of the campaigns recorded alongside the artifacts within dir.
+func List(dir string) ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(dir, Dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("readdir: %w", err)
+	}
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, f := range files {
+		name, ok := strings.CutSuffix(strings.TrimSuffix(f.Name(), encrypt.Extension), ".yaml")
+		if f.IsDir() || !ok || strings.HasPrefix(name, ".") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Find returns the path of an existing campaign record within dir, whether or not it is encrypted.
+// Having both a plaintext and an encrypted record is an error, as either may be stale.
+func Find(dir string, name string) (string, error) {
+	path := Path(dir, name)
+	found := []string{}
+	for _, p := range []string{path, path + encrypt.Extension} {
+		if _, err := os.Stat(p); err == nil {
+			found = append(found, p)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no campaign named %q found within %s", name, filepath.Join(dir, Dir))
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("campaign %q is recorded in both %s and %s: remove the stale one", name, found[0], found[1])
+	}
+}
+
+// Load reads a campaign record, decrypting it with ids if necessary.
+func Load(path string, ids []age.Identity) (*Campaign, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	bs, err = encrypt.Decrypt(bs, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	c := &Campaign{}
+	if err := yaml.Unmarshal(bs, c); err != nil {
+		return nil, fmt.Errorf("%s: unmarshal: %w", path, err)
+	}
+	return c, nil
+}
+
+// Write stores a campaign record at path, encrypting it if any recipients are given, and returns the path written.
+// Encrypted records may only be updated given recipients. Encrypting a plaintext record replaces it.
+func (c *Campaign) Write(path string, recipients []age.Recipient) (string, error) {
+	replaced := ""
+	bs, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("encode: %w", err)
+	}
+
+	encrypted := strings.HasSuffix(path, encrypt.Extension)
+	if encrypted && len(recipients) == 0 {
+		return "", fmt.Errorf("%s is encrypted: recipients are required to update it", path)
+	}
+	if len(recipients) > 0 {
+		bs, err = encrypt.Encrypt(bs, recipients)
+		if err != nil {
+			return "", fmt.Errorf("encrypt: %w", err)
+		}
+		if !encrypted {
+			replaced = path
+			path += encrypt.Extension
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("mkdir: %w", err)
+	}
+	if err := os.WriteFile(path, bs, 0o600); err != nil {
+		return "", fmt.Errorf("writefile: %w", err)
+	}
+	if replaced != "" {
+		if err := os.Remove(replaced); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("remove plaintext record: %w", err)
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,245 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gocarina/gocsv"
+)
+
+// Row is a line of a worksheet: an account to review, and the reviewer's decision.
+// Reviewers mark either Keep or Revoke, such as with an "x".
+type Row struct {
+	Reviewer string `csv:"Reviewer"`
+	Kind     string `csv:"Kind"`
+	ID       string `csv:"ID"`
+	Account  string `csv:"Account"`
+	Name     string `csv:"Name"`
+	Role     string `csv:"Role"`
+	Keep     string `csv:"Keep"`
+	Revoke   string `csv:"Revoke"`
+	Comment  string `csv:"Comment"`
+}
+
+var columns = []string{"Reviewer", "Kind", "ID", "Account", "Name", "Role", "Keep", "Revoke", "Comment"}
+
+func (r Row) cells() []string {
+	return []string{r.Reviewer, r.Kind, r.ID, r.Account, r.Name, r.Role, r.Keep, r.Revoke, r.Comment}
+}
+
+// marks are the values accepted within a decision cell, compared case-insensitively.
+var marks = map[string]bool{"x": true, "[x]": true, "yes": true, "y": true, "✓": true, "✔": true}
+
+// marked returns true if a reviewer marked a decision cell, and an error for anything other than a mark or blank,
+// so that "no" or "n/a" in the Keep column is never taken as a decision to keep.
+func marked(s string) (bool, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "" || s == "[ ]" || s == "[]":
+		return false, nil
+	case marks[s]:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unrecognized mark %q: expected x, yes or ✓", s)
+	}
+}
+
+// rows returns the worksheet rows for a reviewer, including any decisions already made.
+func (c *Campaign) rows(reviewer string) []Row {
+	rows := []Row{}
+	for _, i := range c.Items {
+		if i.Reviewer != reviewer {
+			continue
+		}
+		r := Row{Reviewer: i.Reviewer, Kind: i.Kind, ID: i.ID, Account: i.Account, Name: i.Name, Role: i.Role, Comment: i.Comment}
+		switch i.Decision {
+		case Keep:
+			r.Keep = "x"
+		case Revoke:
+			r.Revoke = "x"
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// CSV returns the worksheet for a reviewer as CSV.
+func (c *Campaign) CSV(reviewer string) ([]byte, error) {
+	rows := c.rows(reviewer)
+	s, err := gocsv.MarshalString(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	return []byte(s), nil
+}
+
+// Markdown returns the worksheet for a reviewer as a Markdown table.
+func (c *Campaign) Markdown(reviewer string) []byte {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# Access review: %s\n\n", c.Name)
+	fmt.Fprintf(b, "Reviewer: %s\n\n", reviewer)
+	b.WriteString("For each account, mark either Keep or Revoke with an `x`, adding a comment where useful.\n\n")
+
+	b.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	b.WriteString(strings.Repeat("| --- ", len(columns)) + "|\n")
+	for _, r := range c.rows(reviewer) {
+		cells := []string{}
+		for _, cell := range r.cells() {
+			cells = append(cells, strings.ReplaceAll(cell, "|", `\|`))
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return []byte(b.String())
+}
+
+// ParseWorksheet reads the rows of a completed worksheet, in CSV or Markdown form depending on its name.
+func ParseWorksheet(name string, bs []byte) ([]Row, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		rows := []Row{}
+		if err := gocsv.UnmarshalBytes(bs, &rows); err != nil {
+			return nil, fmt.Errorf("%s: unmarshal: %w", name, err)
+		}
+		return rows, nil
+	case ".md", ".markdown":
+		return parseMarkdown(name, bs)
+	default:
+		return nil, fmt.Errorf("%s: unknown worksheet format: expected .csv or .md", name)
+	}
+}
+
+func parseMarkdown(name string, bs []byte) ([]Row, error) {
+	rows := []Row{}
+	var header []string
+	for n, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+		cells := splitCells(line)
+		if header == nil {
+			header = cells
+			continue
+		}
+		if strings.Trim(strings.Join(cells, ""), "-: ") == "" {
+			continue
+		}
+		if len(cells) != len(header) {
+			return nil, fmt.Errorf("%s:%d: expected %d cells, found %d", name, n+1, len(header), len(cells))
+		}
+
+		m := map[string]string{}
+		for i, h := range header {
+			m[h] = cells[i]
+		}
+		rows = append(rows, Row{
+			Reviewer: m["Reviewer"], Kind: m["Kind"], ID: m["ID"], Account: m["Account"], Name: m["Name"], Role: m["Role"],
+			Keep: m["Keep"], Revoke: m["Revoke"], Comment: m["Comment"],
+		})
+	}
+	if header == nil {
+		return nil, fmt.Errorf("%s: no table found", name)
+	}
+	return rows, nil
+}
+
+// splitCells splits a Markdown table row into trimmed cells, honoring escaped pipes.
+func splitCells(line string) []string {
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := []string{}
+	cur := &strings.Builder{}
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cur.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cur.String()))
+}
+
+// Ingest records the decisions within a completed worksheet, along with a sign-off by the reviewer it names.
+// Rows left blank remain outstanding, but a worksheet without any decisions is refused.
+// Returns the number of decisions recorded.
+func (c *Campaign) Ingest(name string, bs []byte) (int, error) {
+	rows, err := ParseWorksheet(name, bs)
+	if err != nil {
+		return 0, err
+	}
+
+	idx := map[string]int{}
+	for i, it := range c.Items {
+		idx[it.Kind+"\x00"+it.ID+"\x00"+it.Account] = i
+	}
+
+	reviewer := ""
+	decisions := map[int]Item{}
+	errs := []string{}
+	for n, r := range rows {
+		i, ok := idx[r.Kind+"\x00"+r.ID+"\x00"+r.Account]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("row %d: %s/%s: %s is not part of campaign %q", n+1, r.Kind, r.ID, r.Account, c.Name))
+			continue
+		}
+		it := c.Items[i]
+		if r.Reviewer != it.Reviewer {
+			errs = append(errs, fmt.Sprintf("row %d: %s is reviewed by %q, not %q", n+1, r.Account, it.Reviewer, r.Reviewer))
+			continue
+		}
+		if reviewer != "" && r.Reviewer != reviewer {
+			errs = append(errs, fmt.Sprintf("row %d: worksheet mixes reviewers %q and %q", n+1, reviewer, r.Reviewer))
+			continue
+		}
+		reviewer = r.Reviewer
+
+		keep, kerr := marked(r.Keep)
+		revoke, rerr := marked(r.Revoke)
+		switch {
+		case kerr != nil:
+			errs = append(errs, fmt.Sprintf("row %d: %s: Keep: %v", n+1, r.Account, kerr))
+			continue
+		case rerr != nil:
+			errs = append(errs, fmt.Sprintf("row %d: %s: Revoke: %v", n+1, r.Account, rerr))
+			continue
+		case keep && revoke:
+			errs = append(errs, fmt.Sprintf("row %d: %s is marked as both keep and revoke", n+1, r.Account))
+			continue
+		case keep:
+			it.Decision = Keep
+		case revoke:
+			it.Decision = Revoke
+		default:
+			continue
+		}
+		it.Comment = strings.TrimSpace(r.Comment)
+		decisions[i] = it
+	}
+	if len(errs) > 0 {
+		return 0, fmt.Errorf("%s:\n%s", name, strings.Join(errs, "\n"))
+	}
+	if len(decisions) == 0 {
+		return 0, fmt.Errorf("%s: no decisions found: mark either Keep or Revoke for each account", name)
+	}
+
+	for i, it := range decisions {
+		c.Items[i] = it
+	}
+	sum := sha256.Sum256(bs)
+	c.SignOffs = append(c.SignOffs, SignOff{
+		Reviewer:  reviewer,
+		SignedAt:  time.Now(),
+		Worksheet: filepath.Base(name),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Decisions: len(decisions),
+	})
+	return len(decisions), nil
+}
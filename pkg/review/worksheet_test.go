@@ -0,0 +1,121 @@
+package review
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+func testCampaign(t *testing.T) *Campaign {
+	t.Helper()
+	a := &platform.Artifact{
+		Metadata: &platform.Source{Kind: "vercel", SourceDate: "2024-07-01"},
+		Users:    []platform.User{{Account: "alice@example.com", Role: "owner"}, {Account: "bob@example.com", Role: "member"}},
+	}
+	c, err := New("2024-q3", []*platform.Artifact{a}, &Owners{Default: "sec@example.com"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func decisions(c *Campaign) map[string]string {
+	ds := map[string]string{}
+	for _, i := range c.Items {
+		ds[i.Account] = i.Decision
+	}
+	return ds
+}
+
+func TestWorksheetRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		sheet func(c *Campaign) ([]byte, error)
+		fill  func(ws string) string
+	}{
+		{
+			name:  "csv",
+			file:  "sec.csv",
+			sheet: func(c *Campaign) ([]byte, error) { return c.CSV("sec@example.com") },
+			fill: func(ws string) string {
+				ws = strings.Replace(ws, "alice@example.com,,owner,,,", "alice@example.com,,owner,X,,still needed", 1)
+				return strings.Replace(ws, "bob@example.com,,member,,,", "bob@example.com,,member,,✓,", 1)
+			},
+		},
+		{
+			name:  "markdown",
+			file:  "sec.md",
+			sheet: func(c *Campaign) ([]byte, error) { return c.Markdown("sec@example.com"), nil },
+			fill: func(ws string) string {
+				ws = strings.Replace(ws, "| alice@example.com |  | owner |  |  |  |", "| alice@example.com |  | owner | [x] |  | still needed |", 1)
+				return strings.Replace(ws, "| bob@example.com |  | member |  |  |  |", "| bob@example.com |  | member |  | yes |  |", 1)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := testCampaign(t)
+			ws, err := tc.sheet(c)
+			if err != nil {
+				t.Fatalf("worksheet: %v", err)
+			}
+			filled := tc.fill(string(ws))
+			if filled == string(ws) {
+				t.Fatalf("worksheet was not filled in:\n%s", ws)
+			}
+
+			n, err := c.Ingest(tc.file, []byte(filled))
+			if err != nil {
+				t.Fatalf("Ingest: %v", err)
+			}
+			if n != 2 {
+				t.Errorf("Ingest recorded %d decisions, want 2", n)
+			}
+			want := map[string]string{"alice@example.com": Keep, "bob@example.com": Revoke}
+			for acct, d := range decisions(c) {
+				if d != want[acct] {
+					t.Errorf("%s: decision %q, want %q", acct, d, want[acct])
+				}
+			}
+			if len(c.SignOffs) != 1 || c.SignOffs[0].Reviewer != "sec@example.com" || c.SignOffs[0].SHA256 == "" {
+				t.Errorf("sign-offs = %+v, want one by sec@example.com with a checksum", c.SignOffs)
+			}
+		})
+	}
+}
+
+func TestIngestRejectsBadRows(t *testing.T) {
+	header := "Reviewer,Kind,ID,Account,Name,Role,Keep,Revoke,Comment\n"
+	tests := []struct {
+		name string
+		rows string
+		want string
+	}{
+		{name: "unrecognized mark", rows: "sec@example.com,vercel,vercel,alice@example.com,,owner,no,,\n", want: `unrecognized mark "no"`},
+		{name: "both marked", rows: "sec@example.com,vercel,vercel,alice@example.com,,owner,x,x,\n", want: "both keep and revoke"},
+		{name: "wrong reviewer", rows: "eve@example.com,vercel,vercel,alice@example.com,,owner,x,,\n", want: `reviewed by "sec@example.com"`},
+		{name: "unknown account", rows: "sec@example.com,vercel,vercel,mallory@example.com,,owner,x,,\n", want: "is not part of campaign"},
+		{name: "no decisions", rows: "sec@example.com,vercel,vercel,alice@example.com,,owner,,,\n", want: "no decisions found"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := testCampaign(t)
+			_, err := c.Ingest("sec.csv", []byte(header+tc.rows))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Ingest error = %v, want %q", err, tc.want)
+			}
+			for acct, d := range decisions(c) {
+				if d != "" {
+					t.Errorf("%s: decision %q recorded despite the error", acct, d)
+				}
+			}
+			if len(c.SignOffs) != 0 {
+				t.Errorf("sign-offs = %+v, want none", c.SignOffs)
+			}
+		})
+	}
+}
@@ -1,22 +1,35 @@
 package server
 
 import (
+	"context"
 	"embed"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
 	"os"
 	"runtime"
+	"time"
 
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/config"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
 	"github.com/chainguard-dev/yacls/v2/pkg/platform"
-	"gopkg.in/yaml.v3"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
 	"k8s.io/klog/v2"
 )
 
 //go:embed *.tmpl
 var content embed.FS
 
-type Server struct{}
+type Server struct {
+	// Identities are used to decrypt age-encrypted uploads
+	Identities []age.Identity
+	// Timeout bounds how long processing a single upload may take, if non-zero
+	Timeout time.Duration
+	// Snapshots reads the snapshots behind the account timeline, which is disabled if nil
+	Snapshots func(ctx context.Context) ([]yacls.Snapshot, error)
+}
 
 func New() *Server {
 	server := &Server{}
@@ -26,6 +39,7 @@ func New() *Server {
 func (s *Server) Serve() error {
 	http.HandleFunc("/", s.Root())
 	http.HandleFunc("/healthz", s.Healthz())
+	http.HandleFunc("/timeline", s.Timeline())
 
 	listenAddr := fmt.Sprintf(":%s", os.Getenv("PORT"))
 	if listenAddr == ":" {
@@ -68,6 +82,7 @@ func (s *Server) Root() http.HandlerFunc {
 		var desc platform.ProcessorDescription
 		klog.Infof("chosen: %s", chosen)
 		var output []byte
+		var diagnostics []platform.Diagnostic
 
 		if chosen != "" {
 			proc, err = platform.New(chosen)
@@ -85,30 +100,109 @@ func (s *Server) Root() http.HandlerFunc {
 				return
 			}
 
-			a, err := proc.Process(platform.Config{
-				Path:    "",
-				Reader:  f,
-				Project: project,
-			})
+			bs, err := io.ReadAll(f)
+			if err != nil {
+				s.error(w, err)
+				return
+			}
 
-			platform.FinalizeArtifact(a)
-			output, err = yaml.Marshal(a)
+			bs, err = encrypt.Decrypt(bs, s.Identities)
 			if err != nil {
 				s.error(w, err)
+				return
+			}
+
+			as, err := yacls.Generate(r.Context(), []yacls.Job{{
+				Input:  yacls.Input{Content: bs},
+				Source: config.Source{Kind: chosen, Project: project, Timeout: s.Timeout},
+			}}, yacls.Options{})
+			if err != nil {
+				s.error(w, err)
+				return
+			}
+
+			for _, a := range as {
+				diagnostics = append(diagnostics, a.Metadata.Diagnostics...)
+				bs, err := yacls.Encode(a)
+				if err != nil {
+					s.error(w, err)
+					return
+				}
+				output = append(output, bs...)
 			}
 		}
 
 		klog.Infof("desc:")
 		data := struct {
-			Available []platform.Processor
-			Chosen    string
-			Desc      platform.ProcessorDescription
-			Output    []byte
+			Available   []platform.Processor
+			Chosen      string
+			Desc        platform.ProcessorDescription
+			Output      []byte
+			Diagnostics []platform.Diagnostic
+		}{
+			Available:   platform.Available(),
+			Chosen:      chosen,
+			Desc:        desc,
+			Output:      output,
+			Diagnostics: diagnostics,
+		}
+
+		if err := t.Execute(w, data); err != nil {
+			s.error(w, err)
+			return
+		}
+	}
+}
+
+// Timeline lists the accounts found across snapshots, or the dated history of the account given by the "account" parameter.
+func (s *Server) Timeline() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.Snapshots == nil {
+			http.Error(w, "no snapshots configured: see --timeline-dirs or --timeline-git", http.StatusNotFound)
+			return
+		}
+
+		t, err := template.ParseFS(content, "timeline.tmpl")
+		if err != nil {
+			s.error(w, err)
+			return
+		}
+
+		snaps, err := s.Snapshots(r.Context())
+		if len(snaps) == 0 {
+			s.error(w, fmt.Errorf("no snapshots found: %w", err))
+			return
+		}
+		if err != nil {
+			klog.Warningf("some artifacts could not be read: %v", err)
+		}
+
+		hs, err := yacls.Timeline(snaps)
+		if err != nil {
+			klog.Warningf("some snapshots could not be compared: %v", err)
+		}
+
+		account := r.FormValue("account")
+		var chosen *yacls.History
+		for i := range hs {
+			if hs[i].Entity == account {
+				chosen = &hs[i]
+			}
+		}
+
+		data := struct {
+			Snapshots   []yacls.Snapshot
+			First, Last yacls.Snapshot
+			Histories   []yacls.History
+			Account     string
+			Chosen      *yacls.History
 		}{
-			Available: platform.Available(),
+			Snapshots: snaps,
+			First:     snaps[0],
+			Last:      snaps[len(snaps)-1],
+			Histories: hs,
+			Account:   account,
 			Chosen:    chosen,
-			Desc:      desc,
-			Output:    output,
 		}
 
 		if err := t.Execute(w, data); err != nil {
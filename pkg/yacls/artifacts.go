@@ -0,0 +1,115 @@
+package yacls
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+)
+
+// Encode returns the YAML form of an artifact, tuned for readability within diffs.
+func Encode(a *platform.Artifact) ([]byte, error) {
+	bs, err := yaml.Marshal(a)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	// Improve readability by adding a newline before each account
+	bs = bytes.ReplaceAll(bs, []byte("    - account"), []byte("\n    - account"))
+	// Remove the first double newline
+	bs = bytes.Replace(bs, []byte("\n\n"), []byte("\n"), 1)
+	return bs, nil
+}
+
+// Filename returns the name an artifact is stored as: <kind>.yaml, or <kind>_<id>.yaml.
+func Filename(a *platform.Artifact) string {
+	if a.Metadata.ID != "" {
+		return a.Metadata.Kind + "_" + a.Metadata.ID + ".yaml"
+	}
+	return a.Metadata.Kind + ".yaml"
+}
+
+// WriteArtifact stores an artifact within dir, encrypting it if any recipients are given, and returns its path.
+// Any copy of the artifact stored in the other form is removed, so that a stale plaintext or encrypted copy is
+// never read in its place.
+func WriteArtifact(dir string, a *platform.Artifact, recipients []age.Recipient) (string, error) {
+	bs, err := Encode(a)
+	if err != nil {
+		return "", err
+	}
+
+	name := Filename(a)
+	stale := name + encrypt.Extension
+	if len(recipients) > 0 {
+		bs, err = encrypt.Encrypt(bs, recipients)
+		if err != nil {
+			return "", fmt.Errorf("encrypt: %w", err)
+		}
+		name, stale = stale, name
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, bs, 0o600); err != nil {
+		return "", fmt.Errorf("writefile: %w", err)
+	}
+	klog.Infof("wrote to %s (%d bytes)", path, len(bs))
+
+	stale = filepath.Join(dir, stale)
+	if err := os.Remove(stale); err == nil {
+		klog.Infof("removed %s, replaced by %s", stale, path)
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("remove %s: %w", stale, err)
+	}
+	return path, nil
+}
+
+// ReadFile reads a file, transparently decrypting age-encrypted content with ids.
+func ReadFile(path string, ids []age.Identity) ([]byte, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	bs, err = encrypt.Decrypt(bs, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bs, nil
+}
+
+// LoadArtifact reads a previously generated YAML artifact, decrypting it with ids if necessary.
+func LoadArtifact(path string, ids []age.Identity) (*platform.Artifact, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	a, err := DecodeArtifact(bs, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+// DecodeArtifact parses a stored artifact, decrypting it with ids if necessary.
+func DecodeArtifact(bs []byte, ids []age.Identity) (*platform.Artifact, error) {
+	bs, err := encrypt.Decrypt(bs, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &platform.Artifact{}
+	if err := yaml.Unmarshal(bs, a); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	if a.Metadata == nil {
+		return nil, fmt.Errorf("not an artifact: no metadata found")
+	}
+	return a, nil
+}
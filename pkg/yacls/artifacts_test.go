@@ -0,0 +1,60 @@
+package yacls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+func TestWriteArtifactReplacesOtherForm(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+	encrypted := []age.Recipient{id.Recipient()}
+
+	tests := []struct {
+		name   string
+		first  []age.Recipient
+		second []age.Recipient
+		want   string
+		gone   string
+	}{
+		{name: "encrypting replaces plaintext", first: nil, second: encrypted, want: "slack.yaml.age", gone: "slack.yaml"},
+		{name: "plaintext replaces encrypted", first: encrypted, second: nil, want: "slack.yaml", gone: "slack.yaml.age"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			old := &platform.Artifact{Metadata: &platform.Source{Kind: "slack"}, Users: []platform.User{{Account: "old@example.com"}}}
+			if _, err := WriteArtifact(dir, old, tc.first); err != nil {
+				t.Fatalf("write old: %v", err)
+			}
+			current := &platform.Artifact{Metadata: &platform.Source{Kind: "slack"}, Users: []platform.User{{Account: "new@example.com"}}}
+			path, err := WriteArtifact(dir, current, tc.second)
+			if err != nil {
+				t.Fatalf("write new: %v", err)
+			}
+
+			if got := filepath.Base(path); got != tc.want {
+				t.Errorf("path = %s, want %s", got, tc.want)
+			}
+			if _, err := os.Stat(filepath.Join(dir, tc.gone)); !os.IsNotExist(err) {
+				t.Errorf("%s still exists (err=%v)", tc.gone, err)
+			}
+
+			as, err := LoadDir(dir, []age.Identity{id})
+			if err != nil {
+				t.Fatalf("LoadDir: %v", err)
+			}
+			a := as[SourceKey(current)]
+			if a == nil || len(a.Users) != 1 || a.Users[0].Account != "new@example.com" {
+				t.Errorf("LoadDir read %+v, want the new artifact", a)
+			}
+		})
+	}
+}
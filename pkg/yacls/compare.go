@@ -0,0 +1,153 @@
+package yacls
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+// CompareFiles summarizes the changes between two stored artifacts.
+func CompareFiles(fromPath string, toPath string, ids []age.Identity) ([]compare.Change, error) {
+	from, err := LoadArtifact(fromPath, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := LoadArtifact(toPath, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return compare.Summary(*from, *to)
+}
+
+// IsArtifactPath returns true if a path appears to be a stored artifact, judging by its name.
+func IsArtifactPath(path string) bool {
+	base := strings.TrimSuffix(filepath.Base(path), encrypt.Extension)
+	if strings.HasPrefix(base, ".") {
+		return false
+	}
+	ext := filepath.Ext(base)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// SourceKey identifies the source an artifact describes, regardless of the file it was stored as.
+func SourceKey(a *platform.Artifact) string {
+	if a.Metadata.ID == "" {
+		return a.Metadata.Kind
+	}
+	return a.Metadata.Kind + "/" + a.Metadata.ID
+}
+
+// sourceSet collects artifacts keyed by source, along with the files which could not be used.
+type sourceSet struct {
+	artifacts map[string]*platform.Artifact
+	paths     map[string]string
+	errs      []error
+}
+
+func newSourceSet() *sourceSet {
+	return &sourceSet{artifacts: map[string]*platform.Artifact{}, paths: map[string]string{}}
+}
+
+func (s *sourceSet) add(path string, a *platform.Artifact, err error) {
+	if err != nil {
+		s.errs = append(s.errs, err)
+		return
+	}
+
+	key := SourceKey(a)
+	if prev, ok := s.paths[key]; ok {
+		s.errs = append(s.errs, fmt.Errorf("%s: duplicates the source within %s, skipping", path, prev))
+		return
+	}
+	s.artifacts[key] = a
+	s.paths[key] = path
+}
+
+// LoadDir reads every artifact stored directly within dir, keyed by kind and ID.
+// Files which cannot be read are skipped, and reported together within the returned error.
+func LoadDir(dir string, ids []age.Identity) (map[string]*platform.Artifact, error) {
+	artifacts, _, err := LoadDirPaths(dir, ids)
+	return artifacts, err
+}
+
+// LoadDirPaths is LoadDir, also returning the path each artifact was read from, keyed by kind and ID.
+func LoadDirPaths(dir string, ids []age.Identity) (map[string]*platform.Artifact, map[string]string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("readdir: %w", err)
+	}
+
+	s := newSourceSet()
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name())
+		if f.IsDir() || !IsArtifactPath(path) {
+			continue
+		}
+		a, err := LoadArtifact(path, ids)
+		s.add(path, a, err)
+	}
+	return s.artifacts, s.paths, errors.Join(s.errs...)
+}
+
+// CompareDirs summarizes the changes between the artifacts within fromDir and toDir, pairing them by kind and ID.
+// Sources which appear on only one side are reported as added or removed. Files which cannot be read are skipped,
+// and reported together within the returned error alongside the changes which could be found.
+func CompareDirs(fromDir string, toDir string, ids []age.Identity) ([]compare.Change, error) {
+	from, ferr := LoadDir(fromDir, ids)
+	if from == nil {
+		return nil, ferr
+	}
+
+	to, terr := LoadDir(toDir, ids)
+	if to == nil {
+		return nil, terr
+	}
+
+	changes, err := CompareSources(from, to)
+	return changes, errors.Join(ferr, terr, err)
+}
+
+// CompareSources summarizes the changes between two sets of artifacts keyed by SourceKey.
+// Sources which appear on only one side are reported as added or removed.
+func CompareSources(from map[string]*platform.Artifact, to map[string]*platform.Artifact) ([]compare.Change, error) {
+	keys := []string{}
+	for k := range from {
+		keys = append(keys, k)
+	}
+	for k := range to {
+		if from[k] == nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []compare.Change{}
+	errs := []error{}
+	for _, k := range keys {
+		f, t := from[k], to[k]
+		switch {
+		case t == nil:
+			changes = append(changes, compare.SourceRemoved(*f))
+		case f == nil:
+			changes = append(changes, compare.SourceAdded(*t))
+		default:
+			cs, err := compare.Summary(*f, *t)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", k, err))
+				continue
+			}
+			changes = append(changes, cs...)
+		}
+	}
+	return changes, errors.Join(errs...)
+}
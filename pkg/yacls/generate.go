@@ -0,0 +1,161 @@
+// Package yacls generates, stores and compares access control artifacts: it is the library behind the yacls command.
+package yacls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/config"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"k8s.io/klog/v2"
+)
+
+// Job is a single input to process on behalf of a source.
+// An empty Source.Kind is detected from the input, and an empty Input is valid for kinds which require none.
+type Job struct {
+	Input  Input
+	Source config.Source
+}
+
+// Options control how jobs are processed.
+type Options struct {
+	// Workers is the number of jobs processed concurrently, defaulting to one
+	Workers int
+	// GCPMemberCache is shared across jobs to avoid repeated group lookups, and may be nil
+	GCPMemberCache *platform.GCPMemberCache
+}
+
+// Generate processes jobs into finished artifacts, merging partial exports of the same source.
+func Generate(ctx context.Context, jobs []Job, opts Options) ([]*platform.Artifact, error) {
+	results, err := Process(ctx, jobs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []*platform.Artifact{}
+	for _, a := range results {
+		if a != nil {
+			artifacts = append(artifacts, a)
+		}
+	}
+	return Finalize(artifacts), nil
+}
+
+// Finalize merges partial exports of the same source, such as paginated member lists, and fills in summary fields.
+func Finalize(artifacts []*platform.Artifact) []*platform.Artifact {
+	artifacts = platform.MergeArtifacts(artifacts)
+	for _, a := range artifacts {
+		platform.FinalizeArtifact(a)
+	}
+	return artifacts
+}
+
+// Process generates an unfinished artifact for each job concurrently, detecting the kind of inputs whose source has none.
+// Results are in the same order as jobs, with nil for contained inputs that were skipped.
+// The first job to fail cancels the others, as errgroup does, and its failure is returned.
+func Process(ctx context.Context, jobs []Job, opts Options) ([]*platform.Artifact, error) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	artifacts := make([]*platform.Artifact, len(jobs))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for x, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			a, err := ProcessJob(ctx, j, opts.GCPMemberCache)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("%s: %w", j.Source, err)
+					cancel()
+				})
+				return
+			}
+			artifacts[x] = a
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return artifacts, nil
+}
+
+// ProcessJob generates an unfinished artifact from a single job, returning nil if a contained input should be skipped.
+func ProcessJob(ctx context.Context, j Job, gcpMemberCache *platform.GCPMemberCache) (*platform.Artifact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if j.Source.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Source.Timeout)
+		defer cancel()
+	}
+
+	i := j.Input
+	var f io.Reader
+	if i.Path != "" || i.Content != nil {
+		f = bytes.NewReader(i.Content)
+	}
+
+	name := strings.TrimSuffix(i.Path, encrypt.Extension)
+	kind := j.Source.Kind
+	if kind == "" {
+		var err error
+		kind, err = platform.SuggestKindFromContent(name, i.Content)
+		if err != nil {
+			// directories and archives may contain files which aren't inputs
+			if i.Contained {
+				klog.Warningf("skipping %s: %v", i.Path, err)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("suggest kind: %w", err)
+		}
+	}
+
+	klog.Infof("kind: %q", kind)
+	p, err := platform.New(kind)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %q platform: %w", kind, err)
+	}
+
+	if i.Contained && !platform.Recognizes(p, name, i.Content) {
+		klog.Warningf("skipping %s: does not appear to be a %s input", i.Path, kind)
+		return nil, nil
+	}
+
+	a, err := p.Process(ctx, platform.Config{
+		Path:               i.Path,
+		Reader:             f,
+		ModTime:            i.ModTime,
+		Project:            j.Source.Project,
+		Kind:               kind,
+		GCPIdentityProject: j.Source.GCPIdentityProject,
+		GCPMemberCache:     gcpMemberCache,
+	})
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("process failed: timed out after %s: %w", j.Source.Timeout, err)
+		}
+		return nil, fmt.Errorf("process failed: %w", err)
+	}
+	return a, nil
+}
@@ -0,0 +1,53 @@
+package yacls
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/config"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+var registerBlocking sync.Once
+
+// blockingProcessor waits until its context is done, like a hung gcloud call.
+type blockingProcessor struct{}
+
+func (p *blockingProcessor) Description() platform.ProcessorDescription {
+	return platform.ProcessorDescription{Kind: "test_blocking", Name: "Blocking"}
+}
+
+func (p *blockingProcessor) Process(ctx context.Context, c platform.Config) (*platform.Artifact, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestProcessCancelsOnFailure(t *testing.T) {
+	registerBlocking.Do(func() {
+		if err := platform.Register(&blockingProcessor{}); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	})
+
+	jobs := []Job{
+		{Source: config.Source{Kind: "test_blocking"}},
+		{Source: config.Source{Kind: "test_unknown"}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := Process(ctx, jobs, Options{Workers: 2})
+	if err == nil {
+		t.Fatal("Process succeeded, want the unknown kind to fail")
+	}
+	if !strings.Contains(err.Error(), "test_unknown") {
+		t.Errorf("Process returned %v, want the failure of the unknown kind", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Process took %s, want the blocking job to be cancelled", time.Since(start))
+	}
+}
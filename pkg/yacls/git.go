@@ -0,0 +1,138 @@
+package yacls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"k8s.io/klog/v2"
+)
+
+// BeforePrefix introduces a revision naming the last commit older than an age or date, such as "before:90d",
+// "before:2160h" or "before:2024-01-01".
+const BeforePrefix = "before:"
+
+// git runs a git command within dir, which may be anywhere within a local repository.
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	klog.V(1).Infof("executing %s", cmd)
+	stdout, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s: %w\nstderr: %s", cmd, err, ee.Stderr)
+		}
+		return nil, fmt.Errorf("%s: %w", cmd, err)
+	}
+	return stdout, nil
+}
+
+// parseBefore turns the age or date of a "before:" revision into a form git understands.
+func parseBefore(when string, now time.Time) string {
+	if days, ok := strings.CutSuffix(when, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n).Format(time.RFC3339)
+		}
+	}
+	if d, err := time.ParseDuration(when); err == nil {
+		return now.Add(-d).Format(time.RFC3339)
+	}
+	// leave dates and git's own relative formats ("3 months ago") to git
+	return when
+}
+
+// ResolveRevision returns the commit a revision names within the repository containing dir.
+// In addition to anything git understands, such as "HEAD~1" or a tag, revisions may use BeforePrefix.
+func ResolveRevision(ctx context.Context, dir string, rev string) (string, error) {
+	if when, ok := strings.CutPrefix(rev, BeforePrefix); ok {
+		before := parseBefore(when, time.Now())
+		out, err := git(ctx, dir, "rev-list", "-1", "--before="+before, "HEAD")
+		if err != nil {
+			return "", err
+		}
+		commit := strings.TrimSpace(string(out))
+		if commit == "" {
+			return "", fmt.Errorf("no commit found older than %s", before)
+		}
+		klog.Infof("resolved %s to %s", rev, commit)
+		return commit, nil
+	}
+
+	out, err := git(ctx, dir, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// LoadRevision reads every artifact stored directly within dir as of a git revision, keyed by kind and ID.
+// Artifacts are read from the object store, so the working tree is left alone.
+// Files which cannot be read are skipped, and reported together within the returned error.
+func LoadRevision(ctx context.Context, dir string, rev string, ids []age.Identity) (map[string]*platform.Artifact, error) {
+	commit, err := ResolveRevision(ctx, dir, rev)
+	if err != nil {
+		return nil, err
+	}
+	return loadTree(ctx, dir, rev, commit, ".", ids)
+}
+
+// loadTree reads the artifacts matching pathspec within dir as of a commit, naming them after rev.
+func loadTree(ctx context.Context, dir string, rev string, commit string, pathspec string, ids []age.Identity) (map[string]*platform.Artifact, error) {
+	// paths are relative to dir, which need not be the top of the repository
+	out, err := git(ctx, dir, "ls-tree", "-z", commit, "--", pathspec)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSourceSet()
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		// <mode> SP <type> SP <object> TAB <path>
+		meta, path, ok := strings.Cut(string(entry), "\t")
+		if !ok || !strings.Contains(meta, " blob ") || !IsArtifactPath(path) {
+			continue
+		}
+
+		name := rev + ":" + path
+		bs, err := git(ctx, dir, "cat-file", "blob", commit+":./"+path)
+		if err != nil {
+			s.add(name, nil, err)
+			continue
+		}
+		a, err := DecodeArtifact(bs, ids)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", name, err)
+		}
+		s.add(name, a, err)
+	}
+	return s.artifacts, errors.Join(s.errs...)
+}
+
+// CompareRevisions summarizes the changes to the artifacts within dir between two git revisions.
+// If toRev is empty, the artifacts currently within dir are used instead.
+func CompareRevisions(ctx context.Context, dir string, fromRev string, toRev string, ids []age.Identity) ([]compare.Change, error) {
+	from, ferr := LoadRevision(ctx, dir, fromRev, ids)
+	if from == nil {
+		return nil, ferr
+	}
+
+	var to map[string]*platform.Artifact
+	var terr error
+	if toRev == "" {
+		to, terr = LoadDir(dir, ids)
+	} else {
+		to, terr = LoadRevision(ctx, dir, toRev, ids)
+	}
+	if to == nil {
+		return nil, terr
+	}
+
+	changes, err := CompareSources(from, to)
+	return changes, errors.Join(ferr, terr, err)
+}
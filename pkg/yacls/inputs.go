@@ -0,0 +1,179 @@
+package yacls
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
+	"k8s.io/klog/v2"
+)
+
+// Input is a single file to process, which may have been found within a directory or zip archive.
+type Input struct {
+	Path    string
+	Content []byte
+	ModTime time.Time
+
+	// Contained inputs were found within a directory or archive, alongside files which may not be inputs at all
+	Contained bool
+}
+
+// assetExtensions are files which accompany saved pages or exports, but are never inputs themselves.
+var assetExtensions = map[string]bool{
+	".css":   true,
+	".gif":   true,
+	".ico":   true,
+	".jpeg":  true,
+	".jpg":   true,
+	".js":    true,
+	".map":   true,
+	".png":   true,
+	".svg":   true,
+	".ttf":   true,
+	".webp":  true,
+	".woff":  true,
+	".woff2": true,
+}
+
+// SkipPath returns true for paths within a directory or archive which should not be considered as inputs.
+func SkipPath(p string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(p), "/") {
+		// "Save page complete" stores page assets within <name>_files/
+		if strings.HasSuffix(part, "_files") || part == "__MACOSX" || strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return assetExtensions[strings.ToLower(path.Ext(p))]
+}
+
+// ReadInputs returns the inputs found at paths, decrypting them with ids if necessary.
+// Directories are walked and zip archives are opened, so raw downloads may be used without unpacking.
+func ReadInputs(paths []string, ids []age.Identity) ([]Input, error) {
+	inputs := []Input{}
+	for _, p := range paths {
+		is, err := expandPath(p, false, ids)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, is...)
+	}
+	return inputs, nil
+}
+
+// DirInputs returns the inputs found directly within dir, each of which may itself be a directory or zip archive.
+func DirInputs(dir string, ids []age.Identity) ([]Input, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("readdir: %w", err)
+	}
+
+	paths := []string{}
+	for _, f := range files {
+		if SkipPath(f.Name()) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, f.Name()))
+	}
+	return ReadInputs(paths, ids)
+}
+
+func expandPath(p string, contained bool, ids []age.Identity) ([]Input, error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+
+	if !fi.IsDir() {
+		return expandFile(p, fi.ModTime(), contained, ids)
+	}
+
+	inputs := []Input{}
+	err = filepath.WalkDir(p, func(sub string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(p, sub)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if SkipPath(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		is, err := expandFile(sub, info.ModTime(), true, ids)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, is...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", p, err)
+	}
+	return inputs, nil
+}
+
+func expandFile(p string, mtime time.Time, contained bool, ids []age.Identity) ([]Input, error) {
+	bs, err := ReadFile(p, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bs, []byte("PK\x03\x04")) {
+		return expandZip(p, bs)
+	}
+
+	klog.Infof("found input file: %s", p)
+	return []Input{{Path: p, Content: bs, ModTime: mtime, Contained: contained}}, nil
+}
+
+func expandZip(p string, bs []byte) ([]Input, error) {
+	zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+	if err != nil {
+		return nil, fmt.Errorf("zip %s: %w", p, err)
+	}
+
+	inputs := []Input{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || SkipPath(f.Name) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("zip %s: open %s: %w", p, f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("zip %s: read %s: %w", p, f.Name, err)
+		}
+
+		name := filepath.Join(strings.TrimSuffix(p, encrypt.Extension), filepath.FromSlash(f.Name))
+		klog.Infof("found input file: %s", name)
+		inputs = append(inputs, Input{Path: name, Content: content, ModTime: f.Modified, Contained: true})
+	}
+	return inputs, nil
+}
@@ -0,0 +1,124 @@
+package yacls
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSkipPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "members.html", want: false},
+		{path: "export/users.csv", want: false},
+		{path: "members_files/app.js", want: true},
+		{path: "members_files/data.json", want: true},
+		{path: "__MACOSX/users.csv", want: true},
+		{path: ".DS_Store", want: true},
+		{path: "logo.PNG", want: true},
+	}
+	for _, tc := range tests {
+		if got := SkipPath(tc.path); got != tc.want {
+			t.Errorf("SkipPath(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestReadInputs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, bs []byte) string {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, bs, 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		return p
+	}
+
+	zbuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zbuf)
+	for _, name := range []string{"export/users.csv", "export/", "__MACOSX/export/._users.csv", "export/style.css"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		files     map[string][]byte
+		input     string
+		want      []string
+		contained bool
+	}{
+		{
+			name:  "plain file",
+			files: map[string][]byte{"plain/users.csv": []byte("a,b\n")},
+			input: "plain/users.csv",
+			want:  []string{"plain/users.csv"},
+		},
+		{
+			name: "saved page complete",
+			files: map[string][]byte{
+				"page/members.html":         []byte("<html>"),
+				"page/members_files/app.js": []byte("js"),
+				"page/members_files/x.html": []byte("<html>"),
+				"page/.hidden/members.html": []byte("<html>"),
+			},
+			input:     "page",
+			want:      []string{"page/members.html"},
+			contained: true,
+		},
+		{
+			name:      "zip archive",
+			files:     map[string][]byte{"archive/download.zip": zbuf.Bytes()},
+			input:     "archive/download.zip",
+			want:      []string{"archive/download.zip/export/users.csv"},
+			contained: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, bs := range tc.files {
+				write(name, bs)
+			}
+			is, err := ReadInputs([]string{filepath.Join(dir, tc.input)}, nil)
+			if err != nil {
+				t.Fatalf("ReadInputs: %v", err)
+			}
+			got := []string{}
+			for _, i := range is {
+				rel, err := filepath.Rel(dir, i.Path)
+				if err != nil {
+					t.Fatalf("rel: %v", err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+				if i.Contained != tc.contained {
+					t.Errorf("%s: Contained = %v, want %v", rel, i.Contained, tc.contained)
+				}
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("ReadInputs = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
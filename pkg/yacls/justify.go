@@ -0,0 +1,64 @@
+package yacls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/justify"
+)
+
+// CommitJustifications returns rules justifying the changes made by each commit between two git revisions of dir,
+// using the tickets named within commit messages, such as "Ticket: SEC-123". If toRev is empty, HEAD is used.
+// Rules matching a commit's exact changes come first, followed by rules matching any change to the same accounts.
+func CommitJustifications(ctx context.Context, dir string, fromRev string, toRev string, ids []age.Identity) ([]justify.Rule, error) {
+	if toRev == "" {
+		toRev = "HEAD"
+	}
+	from, err := ResolveRevision(ctx, dir, fromRev)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ResolveRevision(ctx, dir, toRev)
+	if err != nil {
+		return nil, err
+	}
+
+	// <commit> NUL <parents> NUL <message> RS
+	out, err := git(ctx, dir, "log", "--format=%H%x00%P%x00%B%x1e", from+".."+to, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	exact := []justify.Rule{}
+	entity := []justify.Rule{}
+	for _, entry := range strings.Split(string(out), "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(entry), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commit, parents, message := fields[0], strings.Fields(fields[1]), fields[2]
+		ticket := justify.Tickets(message)
+		if ticket == "" || len(parents) == 0 {
+			continue
+		}
+
+		before, err := loadTree(ctx, dir, parents[0], parents[0], ".", ids)
+		if before == nil {
+			return nil, fmt.Errorf("%s: %w", parents[0], err)
+		}
+		after, err := loadTree(ctx, dir, commit, commit, ".", ids)
+		if after == nil {
+			return nil, fmt.Errorf("%s: %w", commit, err)
+		}
+
+		// unreadable artifacts show up in the comparison itself, so only the changes matter here
+		cs, _ := CompareSources(before, after)
+		for _, c := range cs {
+			exact = append(exact, justify.Exact(c, ticket))
+			entity = append(entity, justify.Entity(c, ticket))
+		}
+	}
+	return append(exact, entity...), nil
+}
@@ -0,0 +1,170 @@
+package yacls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+// Snapshot is the set of artifacts stored at one point in time, keyed by SourceKey.
+type Snapshot struct {
+	// Name identifies the snapshot: a directory or an abbreviated commit
+	Name string
+	// Date is when the snapshot was taken, if known, such as the commit date
+	Date      string
+	Artifacts map[string]*platform.Artifact
+}
+
+// DirSnapshots reads a snapshot from each directory, in the order given.
+// Directories which cannot be read at all are skipped, and reported within the returned error.
+func DirSnapshots(dirs []string, ids []age.Identity) ([]Snapshot, error) {
+	snaps := []Snapshot{}
+	errs := []error{}
+	for _, d := range dirs {
+		as, err := LoadDir(d, ids)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d, err))
+		}
+		if as == nil {
+			continue
+		}
+		snaps = append(snaps, Snapshot{Name: d, Artifacts: as})
+	}
+	return snaps, errors.Join(errs...)
+}
+
+// GitSnapshots reads a snapshot from each commit which changed path, oldest first.
+// The path may be a single artifact, or a directory of artifacts within a local git repository.
+func GitSnapshots(ctx context.Context, path string, ids []age.Identity) ([]Snapshot, error) {
+	dir, pathspec := path, "."
+	if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+		dir, pathspec = filepath.Dir(path), filepath.Base(path)
+	}
+
+	out, err := git(ctx, dir, "log", "--reverse", "--format=%H %h %cs", "--", pathspec)
+	if err != nil {
+		return nil, err
+	}
+
+	snaps := []Snapshot{}
+	errs := []error{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		commit, abbrev, date := fields[0], fields[1], fields[2]
+		as, err := loadTree(ctx, dir, abbrev, commit, pathspec, ids)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if as == nil {
+			continue
+		}
+		snaps = append(snaps, Snapshot{Name: abbrev, Date: date, Artifacts: as})
+	}
+	if len(snaps) == 0 && len(errs) == 0 {
+		return nil, fmt.Errorf("%s: no commits found", path)
+	}
+	return snaps, errors.Join(errs...)
+}
+
+// Event is a single change to an account, as observed by a snapshot.
+type Event struct {
+	Entity   string `yaml:"-"`
+	Date     string `yaml:"date,omitempty"`
+	Snapshot string `yaml:"snapshot"`
+	Kind     string `yaml:"kind"`
+	ID       string `yaml:"id"`
+	Mod      string `yaml:"change"`
+}
+
+// History is the dated sequence of changes to a single account, or to a group's permissions.
+type History struct {
+	Entity string  `yaml:"account"`
+	Events []Event `yaml:"events"`
+}
+
+// emptyLike returns an artifact without any accounts, describing the same source as a.
+func emptyLike(a *platform.Artifact, date string) *platform.Artifact {
+	m := *a.Metadata
+	m.SourceDate = date
+	return &platform.Artifact{Metadata: &m}
+}
+
+// Timeline summarizes the changes between each consecutive pair of snapshots, grouped by account.
+// Accounts within the first snapshot, or within a source as it first appears, are reported as added.
+func Timeline(snaps []Snapshot) ([]History, error) {
+	byEntity := map[string][]Event{}
+	errs := []error{}
+	prev := map[string]*platform.Artifact{}
+
+	for _, s := range snaps {
+		keys := []string{}
+		for k := range prev {
+			keys = append(keys, k)
+		}
+		for k := range s.Artifacts {
+			if prev[k] == nil {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			from, to := prev[k], s.Artifacts[k]
+			if from == nil {
+				from = emptyLike(to, "")
+			}
+			if to == nil {
+				to = emptyLike(from, s.Date)
+			}
+
+			cs, err := compare.Summary(*from, *to)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", s.Name, k, err))
+				continue
+			}
+			// Summary walks maps, so settle the order of changes seen together
+			sort.Slice(cs, func(i, j int) bool { return cs[i].Mod < cs[j].Mod })
+
+			for _, c := range cs {
+				byEntity[c.Entity] = append(byEntity[c.Entity], eventFor(c, s))
+			}
+		}
+		prev = s.Artifacts
+	}
+
+	hs := []History{}
+	for e, evs := range byEntity {
+		hs = append(hs, History{Entity: e, Events: evs})
+	}
+	sort.Slice(hs, func(i, j int) bool { return hs[i].Entity < hs[j].Entity })
+	return hs, errors.Join(errs...)
+}
+
+func eventFor(c compare.Change, s Snapshot) Event {
+	// prefer the date the export was taken to the date it was stored
+	date := c.ToDate
+	if date == "" {
+		date = s.Date
+	}
+	return Event{Entity: c.Entity, Date: date, Snapshot: s.Name, Kind: c.Kind, ID: c.ID, Mod: c.Mod}
+}
+
+// Events flattens histories into a single list of events, such as for CSV output.
+func Events(hs []History) []Event {
+	evs := []Event{}
+	for _, h := range hs {
+		evs = append(evs, h.Events...)
+	}
+	return evs
+}
@@ -0,0 +1,70 @@
+package yacls
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+func TestTimeline(t *testing.T) {
+	vercel := func(date string, users ...platform.User) map[string]*platform.Artifact {
+		return map[string]*platform.Artifact{"vercel": {Metadata: &platform.Source{Kind: "vercel", ID: "vercel", SourceDate: date}, Users: users}}
+	}
+
+	tests := []struct {
+		name  string
+		snaps []Snapshot
+		want  map[string][]string
+	}{
+		{
+			name: "first snapshot adds everyone",
+			snaps: []Snapshot{
+				{Name: "q1", Artifacts: vercel("2024-01-05", platform.User{Account: "alice", Role: "owner"})},
+			},
+			want: map[string][]string{"alice": {"q1 2024-01-05 add user"}},
+		},
+		{
+			name: "changes between snapshots",
+			snaps: []Snapshot{
+				{Name: "q1", Artifacts: vercel("2024-01-05", platform.User{Account: "alice", Role: "owner"})},
+				{Name: "q2", Artifacts: vercel("2024-04-05", platform.User{Account: "alice", Role: "member"}, platform.User{Account: "bob"})},
+			},
+			want: map[string][]string{
+				"alice": {"q1 2024-01-05 add user", `q2 2024-04-05 role change: "owner" to "member"`},
+				"bob":   {"q2 2024-04-05 add user"},
+			},
+		},
+		{
+			name: "removed source uses the snapshot date",
+			snaps: []Snapshot{
+				{Name: "abc123", Date: "2024-01-06", Artifacts: vercel("2024-01-05", platform.User{Account: "alice"})},
+				{Name: "def456", Date: "2024-04-06", Artifacts: map[string]*platform.Artifact{}},
+			},
+			want: map[string][]string{
+				"alice": {"abc123 2024-01-05 add user", "def456 2024-04-06 remove user"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hs, err := Timeline(tc.snaps)
+			if err != nil {
+				t.Fatalf("Timeline: %v", err)
+			}
+			if len(hs) != len(tc.want) {
+				t.Fatalf("Timeline returned %d histories, want %d: %+v", len(hs), len(tc.want), hs)
+			}
+			for _, h := range hs {
+				got := []string{}
+				for _, e := range h.Events {
+					got = append(got, e.Snapshot+" "+e.Date+" "+e.Mod)
+				}
+				if !slices.Equal(got, tc.want[h.Entity]) {
+					t.Errorf("%s: events = %q, want %q", h.Entity, got, tc.want[h.Entity])
+				}
+			}
+		})
+	}
+}
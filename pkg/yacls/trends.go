@@ -0,0 +1,208 @@
+package yacls
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+// DefaultPrivilegedRoles matches the roles which grant administrative access on most platforms.
+var DefaultPrivilegedRoles = regexp.MustCompile(`(?i)owner|admin|super`)
+
+// Point is the size of a single source within a single snapshot.
+type Point struct {
+	Date       string
+	Snapshot   string
+	Kind       string
+	ID         string
+	Users      int
+	Bots       int
+	Roles      int
+	Groups     int
+	Privileged int
+	// PrivilegedRoles summarizes ByRole for CSV output, such as "admin=2 owner=1"
+	PrivilegedRoles string
+	// ByRole counts the accounts holding each privileged role
+	ByRole map[string]int `csv:"-"`
+}
+
+// Trends measures each source within each snapshot, in snapshot order.
+// Accounts are privileged if any of their roles or permissions match privileged.
+// When a source has the same date within several snapshots, only the latest is kept.
+func Trends(snaps []Snapshot, privileged *regexp.Regexp) []Point {
+	ps := []Point{}
+	seen := map[string]int{}
+
+	for _, s := range snaps {
+		keys := []string{}
+		for k := range s.Artifacts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			a := s.Artifacts[k]
+			date := a.Metadata.SourceDate
+			if date == "" {
+				date = s.Date
+			}
+
+			p := Point{
+				Date:     date,
+				Snapshot: s.Name,
+				Kind:     a.Metadata.Kind,
+				ID:       a.Metadata.ID,
+				Users:    a.UserCount,
+				Bots:     a.BotCount,
+				Roles:    a.RoleCount,
+				Groups:   a.GroupCount,
+			}
+			if p.ID == "" {
+				p.ID = p.Kind
+			}
+			p.Privileged, p.ByRole = privilegedAccounts(a, privileged)
+
+			roles := []string{}
+			for r, n := range p.ByRole {
+				roles = append(roles, fmt.Sprintf("%s=%d", r, n))
+			}
+			sort.Strings(roles)
+			p.PrivilegedRoles = strings.Join(roles, " ")
+
+			// regenerated artifacts often share an export date: keep the latest
+			at := k + "\x00" + date
+			if i, ok := seen[at]; ok && date != "" {
+				ps[i] = p
+				continue
+			}
+			seen[at] = len(ps)
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// privilegedAccounts returns how many accounts hold a privileged role, and how many hold each privileged role.
+func privilegedAccounts(a *platform.Artifact, privileged *regexp.Regexp) (int, map[string]int) {
+	accounts := map[string]bool{}
+	byRole := map[string]int{}
+
+	visit := func(u platform.User) {
+		roles := map[string]bool{}
+		for _, r := range append(append([]string{u.Role}, u.Roles...), u.Permissions...) {
+			if r != "" && privileged.MatchString(r) {
+				roles[r] = true
+			}
+		}
+		for r := range roles {
+			byRole[r]++
+			accounts[u.Account] = true
+		}
+	}
+
+	for _, us := range [][]platform.User{a.Users, a.Bots, a.ServiceAccounts, a.Principal} {
+		for _, u := range us {
+			visit(u)
+		}
+	}
+	for _, um := range []map[string]platform.User{a.Permissions.Users, a.Permissions.ServiceAccounts, a.Permissions.Principals} {
+		for acct, u := range um {
+			if u.Account == "" {
+				u.Account = acct
+			}
+			visit(u)
+		}
+	}
+	return len(accounts), byRole
+}
+
+// WriteOpenMetrics writes points as gauges in the OpenMetrics text format, timestamped by their date.
+// Points without a parseable date are skipped, as samples of the same series need distinct timestamps.
+func WriteOpenMetrics(w io.Writer, ps []Point) error {
+	type family struct {
+		name  string
+		help  string
+		value func(Point) int
+	}
+	families := []family{
+		{"yacls_users", "Number of user accounts.", func(p Point) int { return p.Users }},
+		{"yacls_bots", "Number of bot accounts.", func(p Point) int { return p.Bots }},
+		{"yacls_roles", "Number of distinct roles.", func(p Point) int { return p.Roles }},
+		{"yacls_groups", "Number of groups.", func(p Point) int { return p.Groups }},
+		{"yacls_privileged_accounts", "Number of accounts holding a privileged role.", func(p Point) int { return p.Privileged }},
+	}
+
+	dated := []Point{}
+	for _, p := range ps {
+		if _, err := time.Parse(platform.SourceDateFormat, p.Date); err == nil {
+			dated = append(dated, p)
+		}
+	}
+	// samples of a series must be contiguous and in time order, whatever order the snapshots were given in
+	sort.SliceStable(dated, func(i, j int) bool {
+		if dated[i].Kind != dated[j].Kind {
+			return dated[i].Kind < dated[j].Kind
+		}
+		if dated[i].ID != dated[j].ID {
+			return dated[i].ID < dated[j].ID
+		}
+		return dated[i].Date < dated[j].Date
+	})
+
+	b := &strings.Builder{}
+	for _, f := range families {
+		fmt.Fprintf(b, "# TYPE %s gauge\n# HELP %s %s\n", f.name, f.name, f.help)
+		for _, p := range dated {
+			fmt.Fprintf(b, "%s{kind=\"%s\",id=\"%s\"} %d %d\n", f.name, escapeLabel(p.Kind), escapeLabel(p.ID), f.value(p), unixDate(p.Date))
+		}
+	}
+
+	type roleSample struct {
+		p    Point
+		role string
+	}
+	rs := []roleSample{}
+	for _, p := range dated {
+		for r := range p.ByRole {
+			rs = append(rs, roleSample{p: p, role: r})
+		}
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		a, b := rs[i], rs[j]
+		if a.p.Kind != b.p.Kind {
+			return a.p.Kind < b.p.Kind
+		}
+		if a.p.ID != b.p.ID {
+			return a.p.ID < b.p.ID
+		}
+		if a.role != b.role {
+			return a.role < b.role
+		}
+		return a.p.Date < b.p.Date
+	})
+
+	b.WriteString("# TYPE yacls_privileged_role_accounts gauge\n# HELP yacls_privileged_role_accounts Number of accounts holding each privileged role.\n")
+	for _, r := range rs {
+		fmt.Fprintf(b, "yacls_privileged_role_accounts{kind=\"%s\",id=\"%s\",role=\"%s\"} %d %d\n", escapeLabel(r.p.Kind), escapeLabel(r.p.ID), escapeLabel(r.role), r.p.ByRole[r.role], unixDate(r.p.Date))
+	}
+	b.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func unixDate(date string) int64 {
+	t, _ := time.Parse(platform.SourceDateFormat, date)
+	return t.Unix()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,109 @@
+package yacls
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	tests := []struct {
+		name string
+		ps   []Point
+		want string
+	}{
+		{
+			name: "series in time order",
+			ps: []Point{
+				{Date: "2024-02-01", Kind: "vercel", ID: "vercel", Users: 3, Privileged: 1, ByRole: map[string]int{"owner": 1}},
+				{Date: "2024-01-01", Kind: "vercel", ID: "vercel", Users: 2, Bots: 1},
+			},
+			want: `# TYPE yacls_users gauge
+# HELP yacls_users Number of user accounts.
+yacls_users{kind="vercel",id="vercel"} 2 1704067200
+yacls_users{kind="vercel",id="vercel"} 3 1706745600
+# TYPE yacls_bots gauge
+# HELP yacls_bots Number of bot accounts.
+yacls_bots{kind="vercel",id="vercel"} 1 1704067200
+yacls_bots{kind="vercel",id="vercel"} 0 1706745600
+# TYPE yacls_roles gauge
+# HELP yacls_roles Number of distinct roles.
+yacls_roles{kind="vercel",id="vercel"} 0 1704067200
+yacls_roles{kind="vercel",id="vercel"} 0 1706745600
+# TYPE yacls_groups gauge
+# HELP yacls_groups Number of groups.
+yacls_groups{kind="vercel",id="vercel"} 0 1704067200
+yacls_groups{kind="vercel",id="vercel"} 0 1706745600
+# TYPE yacls_privileged_accounts gauge
+# HELP yacls_privileged_accounts Number of accounts holding a privileged role.
+yacls_privileged_accounts{kind="vercel",id="vercel"} 0 1704067200
+yacls_privileged_accounts{kind="vercel",id="vercel"} 1 1706745600
+# TYPE yacls_privileged_role_accounts gauge
+# HELP yacls_privileged_role_accounts Number of accounts holding each privileged role.
+yacls_privileged_role_accounts{kind="vercel",id="vercel",role="owner"} 1 1706745600
+# EOF
+`,
+		},
+		{
+			name: "escaped labels and undated points",
+			ps: []Point{
+				{Date: "2024-01-01", Kind: "gcp", ID: `a"b\c`, ByRole: map[string]int{"roles/owner\n": 2}},
+				{Date: "", Kind: "slack", ID: "slack", Users: 9},
+			},
+			want: `# TYPE yacls_users gauge
+# HELP yacls_users Number of user accounts.
+yacls_users{kind="gcp",id="a\"b\\c"} 0 1704067200
+# TYPE yacls_bots gauge
+# HELP yacls_bots Number of bot accounts.
+yacls_bots{kind="gcp",id="a\"b\\c"} 0 1704067200
+# TYPE yacls_roles gauge
+# HELP yacls_roles Number of distinct roles.
+yacls_roles{kind="gcp",id="a\"b\\c"} 0 1704067200
+# TYPE yacls_groups gauge
+# HELP yacls_groups Number of groups.
+yacls_groups{kind="gcp",id="a\"b\\c"} 0 1704067200
+# TYPE yacls_privileged_accounts gauge
+# HELP yacls_privileged_accounts Number of accounts holding a privileged role.
+yacls_privileged_accounts{kind="gcp",id="a\"b\\c"} 0 1704067200
+# TYPE yacls_privileged_role_accounts gauge
+# HELP yacls_privileged_role_accounts Number of accounts holding each privileged role.
+yacls_privileged_role_accounts{kind="gcp",id="a\"b\\c",role="roles/owner\n"} 2 1704067200
+# EOF
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &strings.Builder{}
+			if err := WriteOpenMetrics(b, tc.ps); err != nil {
+				t.Fatalf("WriteOpenMetrics: %v", err)
+			}
+			if b.String() != tc.want {
+				t.Errorf("WriteOpenMetrics =\n%s\nwant:\n%s", b.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestTrendsPrivileged(t *testing.T) {
+	a := &platform.Artifact{
+		Metadata: &platform.Source{Kind: "gcp", ID: "prod", SourceDate: "2024-01-01"},
+		Users:    []platform.User{{Account: "a", Role: "Owner"}, {Account: "b", Role: "member", Permissions: []string{"roles/admin"}}},
+		Permissions: platform.Permissions{Users: map[string]platform.User{
+			"c": {Roles: []string{"roles/owner", "roles/viewer"}},
+		}},
+	}
+	ps := Trends([]Snapshot{{Name: "q1", Artifacts: map[string]*platform.Artifact{"gcp_prod": a}}}, regexp.MustCompile(`(?i)owner|admin`))
+	if len(ps) != 1 {
+		t.Fatalf("Trends returned %d points, want 1", len(ps))
+	}
+	if ps[0].Privileged != 3 {
+		t.Errorf("Privileged = %d, want 3", ps[0].Privileged)
+	}
+	if want := "Owner=1 roles/admin=1 roles/owner=1"; ps[0].PrivilegedRoles != want {
+		t.Errorf("PrivilegedRoles = %q, want %q", ps[0].PrivilegedRoles, want)
+	}
+}
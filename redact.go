@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"github.com/chainguard-dev/yacls/v2/pkg/redact"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+)
+
+func redactCommand() *command {
+	c := newCommand("redact", "<artifact or directory> ...", "Rewrite personal identifiers within existing artifacts into stable pseudonyms, so that they may be shared.")
+	keyFile := c.flags.String("redact-key-file", "", fmt.Sprintf("path to the secret used to derive pseudonyms (default: $%s)", redact.KeyEnv))
+	keepDomain := c.flags.Bool("redact-keep-domain", false, "preserve the e-mail domain of redacted accounts")
+	out := outputFlags(c.flags)
+	common := commonFlags(c.flags)
+
+	c.run = func(_ context.Context, args []string) error {
+		if len(args) == 0 {
+			c.flags.Usage()
+			return fmt.Errorf("no artifacts given")
+		}
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		key, err := redact.LoadKey(*keyFile)
+		if err != nil {
+			return fmt.Errorf("redaction key: %w", err)
+		}
+
+		r, err := redact.New(key, *keepDomain)
+		if err != nil {
+			return fmt.Errorf("redactor: %w", err)
+		}
+
+		paths, err := artifactPaths(args)
+		if err != nil {
+			return err
+		}
+
+		artifacts := []*platform.Artifact{}
+		for _, p := range paths {
+			a, err := yacls.LoadArtifact(p, identities)
+			if err != nil {
+				return err
+			}
+			r.Artifact(a)
+			artifacts = append(artifacts, a)
+		}
+
+		return writeArtifacts(artifacts, out.dir, strings.Split(out.ageRecipients, ","))
+	}
+	return c
+}
+
+// artifactPaths returns the given paths, replacing directories with the files found directly within them.
+func artifactPaths(args []string) ([]string, error) {
+	paths := []string{}
+	for _, arg := range args {
+		fi, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("stat: %w", err)
+		}
+		if !fi.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+
+		files, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, fmt.Errorf("readdir: %w", err)
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			paths = append(paths, filepath.Join(arg, f.Name()))
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"github.com/chainguard-dev/yacls/v2/pkg/review"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+	"k8s.io/klog/v2"
+)
+
+// unsafeFilenameRe matches characters which are replaced within worksheet names.
+var unsafeFilenameRe = regexp.MustCompile(`[^\w@.+-]`)
+
+func reviewCommand() *command {
+	c := newCommand("review", "<start|ingest|status> <artifact dir> [worksheet ...]", "Run access review campaigns: issue worksheets to reviewers, record their decisions with a checksum of each worksheet, and show what is outstanding.")
+	campaign := c.flags.String("campaign", "", "name of the campaign, such as 2024-q3 (status default: every campaign)")
+	owners := c.flags.String("owners", "", "owner mapping file assigning a reviewer to each platform or person (start)")
+	worksheets := c.flags.String("worksheets-dir", "worksheets", "directory to write worksheets to (start)")
+	recipients := c.flags.String("age-recipients", "", "comma-separated list of age recipients (public keys or files) to encrypt the campaign record to")
+	common := commonFlags(c.flags)
+
+	c.run = func(_ context.Context, args []string) error {
+		if len(args) < 2 {
+			c.flags.Usage()
+			return fmt.Errorf("expected an action and an artifact directory")
+		}
+		if err := common.load(); err != nil {
+			return err
+		}
+		rs, err := encrypt.ParseRecipients(strings.Split(*recipients, ","))
+		if err != nil {
+			return err
+		}
+
+		action, dir := args[0], args[1]
+		switch action {
+		case "start":
+			if *campaign == "" || *owners == "" {
+				return fmt.Errorf("start requires --campaign and --owners")
+			}
+			return startReview(dir, *campaign, *owners, *worksheets, rs)
+		case "ingest":
+			if *campaign == "" || len(args) < 3 {
+				return fmt.Errorf("ingest requires --campaign and at least one worksheet")
+			}
+			return ingestReview(dir, *campaign, args[2:], rs)
+		case "status":
+			return reviewStatus(dir, *campaign)
+		default:
+			return fmt.Errorf("unknown action %q: expected start, ingest or status", action)
+		}
+	}
+	return c
+}
+
+// startReview records a new campaign alongside the artifacts within dir, and writes a worksheet for each reviewer.
+func startReview(dir string, name string, ownersPath string, worksheetDir string, rs []age.Recipient) error {
+	if path, err := review.Find(dir, name); err == nil {
+		return fmt.Errorf("campaign %q already exists: %s", name, path)
+	}
+
+	o, err := review.LoadOwners(ownersPath)
+	if err != nil {
+		return err
+	}
+
+	// a campaign must cover every artifact, so refuse to start with any unreadable
+	as, err := loadArtifacts(dir)
+	if err != nil {
+		return err
+	}
+
+	c, err := review.New(name, as, o)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(worksheetDir, 0o700); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	for _, r := range c.Reviewers() {
+		bs, err := c.CSV(r)
+		if err != nil {
+			return err
+		}
+		for path, content := range map[string][]byte{
+			worksheetPath(worksheetDir, name, r, ".csv"): bs,
+			worksheetPath(worksheetDir, name, r, ".md"):  c.Markdown(r),
+		} {
+			if err := os.WriteFile(path, content, 0o600); err != nil {
+				return fmt.Errorf("writefile: %w", err)
+			}
+		}
+		klog.Infof("wrote worksheets for %s to %s", r, worksheetPath(worksheetDir, name, r, ".{csv,md}"))
+	}
+
+	path, err := c.Write(review.Path(dir, name), rs)
+	if err != nil {
+		return err
+	}
+	klog.Infof("started campaign %q covering %d accounts across %d sources: %s", name, len(c.Items), len(c.Sources), path)
+	return nil
+}
+
+// ingestReview records the decisions within completed worksheets.
+func ingestReview(dir string, name string, worksheets []string, rs []age.Recipient) error {
+	path, err := review.Find(dir, name)
+	if err != nil {
+		return err
+	}
+	c, err := review.Load(path, identities)
+	if err != nil {
+		return err
+	}
+
+	for _, ws := range worksheets {
+		bs, err := os.ReadFile(ws)
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		n, err := c.Ingest(ws, bs)
+		if err != nil {
+			return err
+		}
+		klog.Infof("recorded %d decisions from %s", n, ws)
+	}
+
+	if _, err := c.Write(path, rs); err != nil {
+		return err
+	}
+	return reviewStatus(dir, name)
+}
+
+// loadArtifacts reads every artifact within dir, ordered by source.
+func loadArtifacts(dir string) ([]*platform.Artifact, error) {
+	byKey, err := yacls.LoadDir(dir, identities)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	as := []*platform.Artifact{}
+	for _, k := range keys {
+		as = append(as, byKey[k])
+	}
+	return as, nil
+}
+
+func worksheetPath(dir string, campaign string, reviewer string, ext string) string {
+	return filepath.Join(dir, campaign+"_"+unsafeFilenameRe.ReplaceAllString(reviewer, "_")+ext)
+}
+
+func reviewStatus(dir string, name string) error {
+	names := []string{name}
+	if name == "" {
+		var err error
+		names, err = review.List(dir)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no campaigns found within %s", filepath.Join(dir, review.Dir))
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CAMPAIGN\tREVIEWER\tTOTAL\tKEEP\tREVOKE\tOUTSTANDING\tSIGNED OFF")
+	outstanding := 0
+	for _, n := range names {
+		path, err := review.Find(dir, n)
+		if err != nil {
+			return err
+		}
+		c, err := review.Load(path, identities)
+		if err != nil {
+			return err
+		}
+		for _, s := range c.Status() {
+			signed := "-"
+			if !s.SignedAt.IsZero() {
+				signed = s.SignedAt.Format(platform.SourceDateFormat)
+			}
+			if s.Outstanding > 0 {
+				outstanding++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%s\n", c.Name, s.Reviewer, s.Total, s.Keep, s.Revoke, s.Outstanding, signed)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	klog.Infof("%d reviews outstanding", outstanding)
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/config"
+	"github.com/chainguard-dev/yacls/v2/pkg/platform"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+	"k8s.io/klog/v2"
+)
+
+func runCommand() *command {
+	c := newCommand("run", "["+config.DefaultFile+"]", "Generate artifacts for every source described within a project configuration file, reporting any which are missing inputs.")
+	out := outputFlags(c.flags)
+	proc := processingFlags(c.flags)
+	common := commonFlags(c.flags)
+
+	c.run = func(ctx context.Context, args []string) error {
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		path := config.DefaultFile
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		conf, err := config.Load(path)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		klog.Infof("loaded %d sources from %s", len(conf.Sources), path)
+
+		// command-line flags take precedence over the configuration file
+		outDir := conf.Output.Dir
+		if out.dir != "" {
+			outDir = out.dir
+		}
+		recipients := conf.Output.AgeRecipients
+		if out.ageRecipients != "" {
+			recipients = strings.Split(out.ageRecipients, ",")
+		}
+		workers := proc.workers
+		if conf.Workers > 0 && !flagSet(c.flags, "workers") {
+			workers = conf.Workers
+		}
+		if flagSet(c.flags, "timeout") {
+			overrideTimeouts(conf, proc.timeout)
+		}
+
+		return run(ctx, conf, outDir, recipients, workers, proc.timeout)
+	}
+	return c
+}
+
+// run processes every source described within a configuration file, reporting any which are missing inputs.
+func run(ctx context.Context, c *config.Config, outDir string, recipients []string, workers int, timeout time.Duration) error {
+	jobs, owners, err := sourceJobs(c, timeout)
+	if err != nil {
+		return err
+	}
+
+	results, err := yacls.Process(ctx, jobs, yacls.Options{Workers: workers, GCPMemberCache: platform.NewGCPMemberCache()})
+	if err != nil {
+		return err
+	}
+
+	found := make([]bool, len(c.Sources))
+	artifacts := []*platform.Artifact{}
+	for x, a := range results {
+		if a == nil {
+			continue
+		}
+		found[owners[x]] = true
+		artifacts = append(artifacts, a)
+	}
+
+	missing := []config.Source{}
+	for x, s := range c.Sources {
+		if !found[x] {
+			missing = append(missing, s)
+		}
+	}
+
+	artifacts = yacls.Finalize(artifacts)
+	for _, a := range artifacts {
+		reportDiagnostics(a)
+	}
+
+	if err := writeArtifacts(artifacts, outDir, recipients); err != nil {
+		return err
+	}
+
+	for _, s := range missing {
+		klog.Errorf("expected source is missing inputs: %s", s)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%d of %d sources are missing inputs", len(missing), len(c.Sources))
+	}
+	return nil
+}
+
+// overrideTimeouts applies a --timeout given on the command-line to every source, including those with their own.
+func overrideTimeouts(c *config.Config, timeout time.Duration) {
+	for i := range c.Sources {
+		c.Sources[i].Timeout = timeout
+	}
+}
+
+// sourceJobs returns a job for every input of every source within a configuration file, along with the index of
+// the source each job belongs to. Every input is processed together, so that slow sources don't hold up the rest.
+func sourceJobs(c *config.Config, timeout time.Duration) ([]yacls.Job, []int, error) {
+	jobs := []yacls.Job{}
+	owners := []int{}
+	for x, s := range c.Sources {
+		if s.Timeout == 0 {
+			s.Timeout = timeout
+		}
+		inputs, err := sourceInputs(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", s, err)
+		}
+		for _, i := range inputs {
+			jobs = append(jobs, yacls.Job{Input: i, Source: s})
+			owners = append(owners, x)
+		}
+	}
+	return jobs, owners, nil
+}
+
+// sourceInputs returns the inputs matching the glob of a configured source.
+func sourceInputs(s config.Source) ([]yacls.Input, error) {
+	if s.Input == "" {
+		p, err := platform.New(s.Kind)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create %q platform: %w", s.Kind, err)
+		}
+		if p.Description().NoInputRequired {
+			return []yacls.Input{{}}, nil
+		}
+		return nil, nil
+	}
+
+	matches, err := filepath.Glob(s.Input)
+	if err != nil {
+		return nil, fmt.Errorf("glob: %w", err)
+	}
+
+	paths := []string{}
+	for _, m := range matches {
+		if !yacls.SkipPath(filepath.Base(m)) {
+			paths = append(paths, m)
+		}
+	}
+	return yacls.ReadInputs(paths, identities)
+}
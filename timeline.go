@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+	"github.com/gocarina/gocsv"
+	"gopkg.in/yaml.v3"
+)
+
+func timelineCommand() *command {
+	c := newCommand("timeline", "[<dir> ...]", "Show the dated history of each account across snapshots: directories of artifacts, or the git history of an artifact or directory.")
+	gitPath := c.flags.String("git", "", "artifact or directory of artifacts within a local git repository: use each commit which changed it as a snapshot")
+	format := c.flags.String("format", "yaml", "output format: yaml or csv")
+	accounts := c.flags.String("account", "", "comma-separated list of accounts to show (default: all)")
+	common := commonFlags(c.flags)
+
+	c.run = func(ctx context.Context, args []string) error {
+		if (*gitPath == "") == (len(args) == 0) {
+			c.flags.Usage()
+			return fmt.Errorf("expected either --git or snapshot directories")
+		}
+		if *format != "yaml" && *format != "csv" {
+			return fmt.Errorf("unknown format %q: expected yaml or csv", *format)
+		}
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		snaps, serr := snapshots(ctx, args, *gitPath)
+		if len(snaps) == 0 {
+			return fmt.Errorf("no snapshots found: %w", serr)
+		}
+
+		// snapshots continue past unreadable files, so show whatever history could be found
+		hs, terr := yacls.Timeline(snaps)
+		if *accounts != "" {
+			keep := strings.Split(*accounts, ",")
+			hs = slices.DeleteFunc(hs, func(h yacls.History) bool { return !slices.Contains(keep, h.Entity) })
+		}
+
+		var out []byte
+		var merr error
+		if *format == "csv" {
+			evs := yacls.Events(hs)
+			var s string
+			s, merr = gocsv.MarshalString(&evs)
+			out = []byte(s)
+		} else {
+			out, merr = yaml.Marshal(hs)
+		}
+		if merr != nil {
+			return fmt.Errorf("marshal: %w", merr)
+		}
+		fmt.Print(string(out))
+
+		if err := errors.Join(serr, terr); err != nil {
+			return fmt.Errorf("some artifacts could not be read or compared:\n%w", err)
+		}
+		return nil
+	}
+	return c
+}
+
+// snapshots reads snapshots from a list of directories, or from the git history of gitPath if set.
+func snapshots(ctx context.Context, dirs []string, gitPath string) ([]yacls.Snapshot, error) {
+	if gitPath != "" {
+		return yacls.GitSnapshots(ctx, gitPath, identities)
+	}
+	return yacls.DirSnapshots(dirs, identities)
+}
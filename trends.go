@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
+	"github.com/gocarina/gocsv"
+)
+
+func trendsCommand() *command {
+	c := newCommand("trends", "[<dir> ...]", "Output the number of users, bots, roles, groups and privileged accounts of each source over time, as CSV or OpenMetrics.")
+	gitPath := c.flags.String("git", "", "artifact or directory of artifacts within a local git repository: use each commit which changed it as a snapshot")
+	format := c.flags.String("format", "csv", "output format: csv or openmetrics")
+	privileged := c.flags.String("privileged-roles", yacls.DefaultPrivilegedRoles.String(), "regular expression matching privileged roles and permissions")
+	common := commonFlags(c.flags)
+
+	c.run = func(ctx context.Context, args []string) error {
+		if (*gitPath == "") == (len(args) == 0) {
+			c.flags.Usage()
+			return fmt.Errorf("expected either --git or snapshot directories")
+		}
+		if *format != "csv" && *format != "openmetrics" {
+			return fmt.Errorf("unknown format %q: expected csv or openmetrics", *format)
+		}
+		re, err := regexp.Compile(*privileged)
+		if err != nil {
+			return fmt.Errorf("privileged roles: %w", err)
+		}
+		if err := common.load(); err != nil {
+			return err
+		}
+
+		snaps, serr := snapshots(ctx, args, *gitPath)
+		if len(snaps) == 0 {
+			return fmt.Errorf("no snapshots found: %w", serr)
+		}
+
+		// snapshots continue past unreadable files, so output whatever could be measured
+		ps := yacls.Trends(snaps, re)
+		if *format == "openmetrics" {
+			if err := yacls.WriteOpenMetrics(os.Stdout, ps); err != nil {
+				return fmt.Errorf("write: %w", err)
+			}
+		} else {
+			s, err := gocsv.MarshalString(&ps)
+			if err != nil {
+				return fmt.Errorf("marshal: %w", err)
+			}
+			fmt.Print(s)
+		}
+
+		if serr != nil {
+			return fmt.Errorf("some artifacts could not be read:\n%w", serr)
+		}
+		return nil
+	}
+	return c
+}
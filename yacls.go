@@ -1,226 +1,253 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"flag"
 	"fmt"
-	"io"
-	"log"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"runtime"
 	"strings"
+	"text/tabwriter"
+	"time"
 
-	"github.com/chainguard-dev/yacls/v2/pkg/compare"
+	"filippo.io/age"
+	"github.com/chainguard-dev/yacls/v2/pkg/encrypt"
 	"github.com/chainguard-dev/yacls/v2/pkg/platform"
-	"github.com/chainguard-dev/yacls/v2/pkg/server"
-	"github.com/gocarina/gocsv"
-
-	"gopkg.in/yaml.v3"
+	"github.com/chainguard-dev/yacls/v2/pkg/yacls"
 	"k8s.io/klog/v2"
 )
 
-func kindHelp() string {
-	lines := []string{"\nDetailed steps for each kind:\n"}
-	for _, k := range platform.Available() {
-		d := k.Description()
-		lines = append(lines, fmt.Sprintf("# %s\n", d.Name))
-		for _, s := range d.Steps {
-			lines = append(lines, fmt.Sprintf(" * %s", s))
-		}
-		lines = append(lines, "")
+// identities decrypt age-encrypted inputs and artifacts.
+var identities []age.Identity
+
+// command is a yacls subcommand, with its own flags and help.
+type command struct {
+	name    string
+	args    string
+	summary string
+	flags   *flag.FlagSet
+	run     func(ctx context.Context, args []string) error
+}
+
+func newCommand(name string, args string, summary string) *command {
+	c := &command{name: name, args: args, summary: summary, flags: flag.NewFlagSet(name, flag.ExitOnError)}
+	c.flags.Usage = func() {
+		out := c.flags.Output()
+		fmt.Fprintf(out, "Usage: yacls %s [flags] %s\n\n%s\n\nFlags:\n", c.name, c.args, c.summary)
+		c.flags.PrintDefaults()
 	}
-	return strings.Join(lines, "\n")
+	return c
 }
 
-var (
-	inputFlag              = flag.String("input", "", "path to input file")
-	compareFlag            = flag.String("compare", "", "path to file to compare against")
-	projectFlag            = flag.String("project", "", "specific project to process within the kind")
-	gcpIdentityProjectFlag = flag.String("gcp-identity-project", "", "project to use for GCP Cloud Identity lookups")
-	kindFlag               = flag.String("kind", "", fmt.Sprintf("kind of input to process. valid values: \n  * %s\n%s", strings.Join(platform.AvailableKinds(), "\n  * "), kindHelp()))
-	serveFlag              = flag.Bool("serve", false, "Enable server mode (web UI)")
-	inDirFlag              = flag.String("in-dir", "", "process all input files found directly within this directory, guessing kinds")
-	outDirFlag             = flag.String("out-dir", "", "output YAML files to this directory")
-)
+func commands() []*command {
+	return []*command{
+		generateCommand(),
+		runCommand(),
+		compareCommand(),
+		timelineCommand(),
+		trendsCommand(),
+		reviewCommand(),
+		bundleCommand(),
+		controlsCommand(),
+		redactCommand(),
+		serveCommand(),
+		kindsCommand(),
+		explainCommand(),
+	}
+}
 
-func main() {
-	// Pollutes --help with flags no one will need
-	klog.InitFlags(nil)
-	flag.Parse()
-
-	if *serveFlag || os.Getenv("SERVE_MODE") == "1" {
-		s := server.New()
-		if err := s.Serve(); err != nil {
-			log.Fatalf("serve failed: %v", err)
-		}
-		os.Exit(0)
+func usage(cs []*command) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Usage: yacls <command> [flags] [arguments]\n\nCommands:\n")
+	for _, c := range cs {
+		fmt.Fprintf(w, "  %s\t%s\n", c.name, c.summary)
 	}
+	fmt.Fprintf(w, "\nWithout a command, yacls runs 'generate'. Run 'yacls <command> -h' for the flags of a command.\n")
+	w.Flush()
+}
 
-	if *compareFlag != "" {
-		changes := []compare.Change{}
+func main() {
+	cs := commands()
+	args := os.Args[1:]
 
-		if *inDirFlag == "" {
-			cs, err := compareSummary(*inputFlag, *compareFlag)
-			if err != nil {
-				log.Fatalf("compare failed: %v", err)
-			}
-			changes = append(changes, cs...)
-		} else {
-			files, err := os.ReadDir(*inDirFlag)
-			if err != nil {
-				log.Fatal(err)
-			}
+	name := "generate"
+	if os.Getenv("SERVE_MODE") == "1" {
+		name = "serve"
+	}
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-			for _, file := range files {
-				if file.IsDir() {
-					continue
-				}
-				src := filepath.Join(*inDirFlag, file.Name())
-				dest := filepath.Join(*compareFlag, file.Name())
-				cs, err := compareSummary(src, dest)
-				if err != nil {
-					log.Fatalf("compare failed: %v", err)
-				}
-				changes = append(changes, cs...)
+	if name == "help" {
+		for _, c := range cs {
+			if len(args) > 0 && c.name == args[0] {
+				c.flags.SetOutput(os.Stdout)
+				c.flags.Usage()
+				return
 			}
 		}
-		s, err := gocsv.MarshalString(&changes)
-		if err != nil {
-			log.Fatalf("marshal: %v", err)
-		}
-		fmt.Println(s)
-		os.Exit(0)
+		usage(cs)
+		return
 	}
 
-	generate()
-}
-
-func compareSummary(fromPath string, toPath string) ([]compare.Change, error) {
-	cs := []compare.Change{}
-	bs, err := os.ReadFile(fromPath)
-	if err != nil {
-		return cs, fmt.Errorf("read: %w", err)
+	var cmd *command
+	for _, c := range cs {
+		if c.name == name {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command: %q\n\n", name)
+		usage(cs)
+		os.Exit(2)
 	}
 
-	from := platform.Artifact{}
-	if err := yaml.Unmarshal(bs, &from); err != nil {
-		return cs, fmt.Errorf("unmarshal: %w", err)
+	klog.InitFlags(cmd.flags)
+	args, err := parseFlags(cmd.flags, args)
+	if err != nil {
+		klog.Exitf("%s: %v", name, err)
 	}
 
-	bs, err = os.ReadFile(toPath)
+	// interrupting yacls abandons any subprocesses, such as gcloud
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err = cmd.run(ctx, args)
+	stop()
 	if err != nil {
-		return cs, fmt.Errorf("read: %w", err)
+		klog.Exitf("%s: %v", name, err)
 	}
+}
 
-	to := platform.Artifact{}
-	if err := yaml.Unmarshal(bs, &to); err != nil {
-		return cs, fmt.Errorf("unmarshal: %w", err)
+// parseFlags parses flags which may appear before or after positional arguments, returning the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
 	}
+}
 
-	return compare.Summary(from, to)
+// commonOptions are flags shared by every command: processors defined outside of yacls, and decryption.
+type commonOptions struct {
+	definitionsDir string
+	pluginsDir     string
+	ageIdentity    string
 }
 
-// generate is the common path for generating and outputting YAML
-func generate() {
-	inputs := []string{}
-	if *inputFlag != "" {
-		inputs = append(inputs, *inputFlag)
-	}
-	if *inDirFlag != "" {
-		files, err := os.ReadDir(*inDirFlag)
+func commonFlags(fs *flag.FlagSet) *commonOptions {
+	o := &commonOptions{}
+	fs.StringVar(&o.definitionsDir, "definitions-dir", os.Getenv(platform.DefinitionsEnv), fmt.Sprintf("directory of YAML processor definitions to load (default: $%s)", platform.DefinitionsEnv))
+	fs.StringVar(&o.pluginsDir, "plugins-dir", os.Getenv(platform.PluginsEnv), fmt.Sprintf("directory searched for %s<kind> executables before $PATH (default: $%s)", platform.PluginPrefix, platform.PluginsEnv))
+	fs.StringVar(&o.ageIdentity, "age-identity", "", fmt.Sprintf("age identity file used to decrypt encrypted inputs and artifacts (default: $%s)", encrypt.IdentityEnv))
+	return o
+}
+
+// load registers processors defined outside of yacls, and reads any age identity.
+func (o *commonOptions) load() error {
+	if o.definitionsDir != "" {
+		ps, err := platform.LoadDefinitions(o.definitionsDir)
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("load definitions: %w", err)
 		}
-
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-			log.Printf("found input file: %s", file.Name())
-			inputs = append(inputs, filepath.Join(*inDirFlag, file.Name()))
+		if err := platform.Register(ps...); err != nil {
+			return fmt.Errorf("register definitions: %w", err)
 		}
 	}
 
-	// these workflows don't require an input
-	if strings.HasPrefix(*kindFlag, "gcp") {
-		inputs = append(inputs, "")
-	}
+	// plugins are only run once a command needs a processor
+	platform.EnablePlugins(o.pluginsDir)
 
-	if len(inputs) == 0 && *kindFlag == "" {
-		log.Fatalf("found no inputs or kind flag to work with")
+	var err error
+	identities, err = encrypt.LoadIdentities(o.ageIdentity)
+	if err != nil {
+		return fmt.Errorf("age identity: %w", err)
 	}
+	return nil
+}
 
-	gcpMemberCache := platform.NewGCPMemberCache()
-	artifacts := []*platform.Artifact{}
-	var err error
+// outputOptions are flags for commands which write artifacts.
+type outputOptions struct {
+	dir           string
+	ageRecipients string
+}
 
-	for _, i := range inputs {
-		kind := *kindFlag
-		if kind == "" {
-			kind, err = platform.SuggestKind(i)
-			if err != nil {
-				log.Fatalf("suggest kind: %v", err)
-				continue
-			}
-		}
+func outputFlags(fs *flag.FlagSet) *outputOptions {
+	o := &outputOptions{}
+	fs.StringVar(&o.dir, "out-dir", "", "output YAML files to this directory (default: stdout)")
+	fs.StringVar(&o.ageRecipients, "age-recipients", "", "comma-separated age public keys (or files containing them) to encrypt --out-dir artifacts to")
+	return o
+}
 
-		klog.Infof("kind: %q", kind)
-		var f io.ReadCloser
-		p, err := platform.New(kind)
-		if err != nil {
-			klog.Fatalf("unable to create %q platform: %v", *kindFlag, err)
-		}
+// processingOptions are flags for commands which process inputs.
+type processingOptions struct {
+	timeout time.Duration
+	workers int
+}
 
-		if i != "" {
-			f, err = os.Open(i)
-			if err != nil {
-				klog.Fatalf("unable to open: %v", err)
-			}
-			defer f.Close()
-		}
+func processingFlags(fs *flag.FlagSet) *processingOptions {
+	o := &processingOptions{}
+	fs.DurationVar(&o.timeout, "timeout", 0, "time limit for processing each input, such as 10m (default: no limit)")
+	fs.IntVar(&o.workers, "workers", runtime.NumCPU(), "number of inputs to process concurrently")
+	return o
+}
 
-		a, err := p.Process(platform.Config{
-			Path:               i,
-			Reader:             f,
-			Project:            *projectFlag,
-			Kind:               kind,
-			GCPIdentityProject: *gcpIdentityProjectFlag,
-			GCPMemberCache:     gcpMemberCache,
-		})
-		if err != nil {
-			klog.Fatalf("process failed: %v", err)
+// flagSet returns true if a flag was explicitly passed on the command-line.
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
+	})
+	return set
+}
 
-		artifacts = append(artifacts, a)
+// reportDiagnostics logs any oddities found while processing an artifact.
+func reportDiagnostics(a *platform.Artifact) {
+	name := a.Metadata.Kind
+	if a.Metadata.ID != "" {
+		name = a.Metadata.Kind + "/" + a.Metadata.ID
 	}
 
-	for _, a := range artifacts {
-		platform.FinalizeArtifact(a)
-
-		bs, err := yaml.Marshal(a)
-		if err != nil {
-			klog.Exitf("encode: %v", err)
+	for _, d := range a.Metadata.Diagnostics {
+		if d.Severity == platform.SeverityError {
+			klog.Errorf("%s: %s", name, d)
+			continue
 		}
+		klog.Warningf("%s: %s", name, d)
+	}
+}
 
-		// Improve readability by adding a newline before each account
-		bs = bytes.ReplaceAll(bs, []byte("    - account"), []byte("\n    - account"))
-		// Remove the first double newline
-		bs = bytes.Replace(bs, []byte("\n\n"), []byte("\n"), 1)
+// writeArtifacts outputs artifacts to outDir, or stdout if unset, encrypting them to any recipients given.
+func writeArtifacts(artifacts []*platform.Artifact, outDir string, recipientSpecs []string) error {
+	recipients, err := encrypt.ParseRecipients(recipientSpecs)
+	if err != nil {
+		return fmt.Errorf("age recipients: %w", err)
+	}
+	if len(recipients) > 0 && outDir == "" {
+		return fmt.Errorf("encryption requires an output directory")
+	}
 
-		if *outDirFlag != "" {
-			name := a.Metadata.Kind + ".yaml"
-			if a.Metadata.ID != "" {
-				name = a.Metadata.Kind + "_" + a.Metadata.ID + ".yaml"
+	for _, a := range artifacts {
+		if outDir != "" {
+			if _, err := yacls.WriteArtifact(outDir, a, recipients); err != nil {
+				return err
 			}
+			continue
+		}
 
-			outPath := filepath.Join(*outDirFlag, name)
-			err := os.WriteFile(outPath, bs, 0o600)
-			if err != nil {
-				klog.Exitf("writefile: %s", err)
-			}
-			klog.Infof("wrote to %s (%d bytes)", outPath, len(bs))
-		} else {
-			fmt.Printf("---\n%s\n", bs)
+		bs, err := yacls.Encode(a)
+		if err != nil {
+			return err
 		}
+		fmt.Printf("---\n%s\n", bs)
 	}
+	return nil
 }